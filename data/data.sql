@@ -2,24 +2,138 @@ create table if not exists products (
   id varchar(40) not null,
   productName varchar(120),
   description varchar(120),
-  price varchar(45),
-  status varchar(45),
-  primary key (id)
+  price bigint not null default 0,
+  basePrice bigint null,
+  currency char(3) not null default 'USD',
+  status varchar(45) not null default 'draft',
+  available tinyint(1) not null default 0,
+  version int not null default 1,
+  deletedAt datetime null,
+  deletedBy varchar(120) null,
+  primary key (id),
+  fulltext key ft_products_text (productName, description)
 );
 
-insert into products (id, productName, description, price, status) values ('P001', 'Iron Man', 'toys', '1000', 'available');
-insert into products (id, productName, description, price, status) values ('P002', 'Scram411', 'bike', '2000', 'available');
-insert into products (id, productName, description, price, status) values ('P003', 'Ikea 4025', 'furniture', '3000', 'not available');
+insert into products (id, productName, description, price, currency, status, available) values ('P001', 'Iron Man', 'toys', 100000, 'USD', 'active', 1);
+insert into products (id, productName, description, price, currency, status, available) values ('P002', 'Scram411', 'bike', 200000, 'USD', 'active', 1);
+insert into products (id, productName, description, price, currency, status, available) values ('P003', 'Ikea 4025', 'furniture', 300000, 'USD', 'active', 0);
 
 create table if not exists product_details (
     productID varchar(120) not null,
     supplier varchar(120),
-    storage varchar(45),
+    storage varchar(45) not null default '',
     inStockAmount int,
+    primary key (productID, storage),
     FOREIGN KEY (productID) REFERENCES products(id)
     );
 
 insert into product_details (productID, supplier, storage, inStockAmount) values ('P001', 'LEGO inc.', 'north', 1000);
 insert into product_details (productID, supplier, storage, inStockAmount) values ('P002', 'Royal Enfield', 'south', 550);
+insert into product_details (productID, supplier, storage, inStockAmount) values ('P002', 'Royal Enfield', 'central', 120);
 insert into product_details (productID, supplier, storage, inStockAmount) values ('P003', 'Ikea', 'central', 0);
 
+
+create table if not exists product_audits (
+    id bigint not null auto_increment,
+    productId varchar(40) not null,
+    operation varchar(20) not null,
+    actor varchar(120) not null,
+    changedAt datetime(6) not null,
+    beforeState json,
+    afterState json,
+    diff json,
+    primary key (id),
+    index idx_product_audits_product (productId, changedAt)
+    );
+
+create table if not exists product_outbox (
+    id char(36) not null,
+    eventType varchar(40) not null,
+    productId varchar(40) not null,
+    occurredAt datetime(6) not null,
+    payload json not null,
+    attempts int not null default 0,
+    nextAttemptAt datetime(6) not null,
+    publishedAt datetime(6) null,
+    lastError varchar(1000) null,
+    primary key (id),
+    index idx_product_outbox_pending (publishedAt, nextAttemptAt),
+    index idx_product_outbox_product (productId, publishedAt, occurredAt)
+    );
+
+create table if not exists idempotency_keys (
+    actor varchar(120) not null,
+    idempotencyKey varchar(255) not null,
+    requestHash char(64) not null,
+    owner char(32) not null,
+    statusCode int not null default 0,
+    header json null,
+    body mediumblob null,
+    createdAt datetime(6) not null,
+    expiresAt datetime(6) not null,
+    lockedUntil datetime(6) not null,
+    primary key (actor, idempotencyKey),
+    index idx_idempotency_keys_expires (expiresAt)
+    );
+
+create table if not exists product_reservations (
+    id char(36) not null,
+    productId varchar(40) not null,
+    storage varchar(45) not null default '',
+    quantity int not null,
+    status varchar(20) not null,
+    actor varchar(120) not null,
+    createdAt datetime(6) not null,
+    expiresAt datetime(6) not null,
+    updatedAt datetime(6) null,
+    primary key (id),
+    index idx_product_reservations_product (productId, status),
+    index idx_product_reservations_expires (status, expiresAt)
+    );
+
+create table if not exists stock_movements (
+    id bigint not null auto_increment,
+    productId varchar(40) not null,
+    storage varchar(45) not null default '',
+    delta int not null,
+    balance int not null,
+    reason varchar(20) not null,
+    reference varchar(120) null,
+    actor varchar(120) not null,
+    movedAt datetime(6) not null,
+    primary key (id),
+    index idx_stock_movements_product (productId, movedAt)
+    );
+
+create table if not exists product_prices (
+    id varchar(40) not null,
+    productId varchar(40) not null,
+    price bigint not null,
+    effectiveFrom datetime(6) not null,
+    effectiveTo datetime(6) null,
+    priority int not null default 0,
+    actor varchar(120) not null,
+    createdAt datetime(6) not null,
+    primary key (id),
+    index idx_product_prices_product (productId, effectiveFrom)
+    );
+
+create table if not exists price_history (
+    id bigint not null auto_increment,
+    productId varchar(40) not null,
+    price bigint not null,
+    previousPrice bigint null,
+    scheduleId varchar(40) null,
+    actor varchar(120) not null,
+    changedAt datetime(6) not null,
+    primary key (id),
+    index idx_price_history_product (productId, changedAt)
+    );
+
+create table if not exists price_failures (
+    productId varchar(40) not null,
+    attempts int not null default 0,
+    lastError varchar(1000) null,
+    nextAttemptAt datetime(6) not null,
+    primary key (productId)
+    );
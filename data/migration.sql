@@ -0,0 +1,142 @@
+-- Upgrades a database created with the first data.sql to the schema of data.sql, keeping its products.
+-- Run it once, in order; the tables which data.sql adds are created at the end.
+
+-- prices are amounts in the minor units of their currency, and a scheduled price keeps the base price
+update products set price = '0' where price is null or trim(price) = '';
+update products set price = cast(round(cast(price as decimal(19, 2)) * 100) as char);
+alter table products
+  modify price bigint not null default 0,
+  add basePrice bigint null after price,
+  add currency char(3) not null default 'USD' after basePrice;
+
+-- the status is the status of the lifecycle, and whether a product is in stock is told by available
+alter table products add available tinyint(1) not null default 0 after status;
+update products set available = 1 where status = 'available';
+update products set status = 'active' where status in ('available', 'not available');
+update products set status = 'draft' where status is null or status not in ('draft', 'active', 'discontinued', 'archived');
+alter table products modify status varchar(45) not null default 'draft';
+
+-- optimistic concurrency, soft delete and full-text search
+alter table products
+  add version int not null default 1,
+  add deletedAt datetime null,
+  add deletedBy varchar(120) null,
+  add fulltext key ft_products_text (productName, description);
+
+-- one stock row per product and storage: the rows of the same storage are merged, a missing storage is ''
+update product_details set storage = '' where storage is null;
+create table product_details_merged as
+  select productID, max(supplier) as supplier, storage, sum(inStockAmount) as inStockAmount
+  from product_details group by productID, storage;
+delete from product_details;
+alter table product_details
+  modify storage varchar(45) not null default '',
+  add primary key (productID, storage);
+insert into product_details (productID, supplier, storage, inStockAmount)
+  select productID, supplier, storage, inStockAmount from product_details_merged;
+drop table product_details_merged;
+
+create table if not exists product_audits (
+    id bigint not null auto_increment,
+    productId varchar(40) not null,
+    operation varchar(20) not null,
+    actor varchar(120) not null,
+    changedAt datetime(6) not null,
+    beforeState json,
+    afterState json,
+    diff json,
+    primary key (id),
+    index idx_product_audits_product (productId, changedAt)
+    );
+
+create table if not exists product_outbox (
+    id char(36) not null,
+    eventType varchar(40) not null,
+    productId varchar(40) not null,
+    occurredAt datetime(6) not null,
+    payload json not null,
+    attempts int not null default 0,
+    nextAttemptAt datetime(6) not null,
+    publishedAt datetime(6) null,
+    lastError varchar(1000) null,
+    primary key (id),
+    index idx_product_outbox_pending (publishedAt, nextAttemptAt),
+    index idx_product_outbox_product (productId, publishedAt, occurredAt)
+    );
+
+create table if not exists idempotency_keys (
+    actor varchar(120) not null,
+    idempotencyKey varchar(255) not null,
+    requestHash char(64) not null,
+    owner char(32) not null,
+    statusCode int not null default 0,
+    header json null,
+    body mediumblob null,
+    createdAt datetime(6) not null,
+    expiresAt datetime(6) not null,
+    lockedUntil datetime(6) not null,
+    primary key (actor, idempotencyKey),
+    index idx_idempotency_keys_expires (expiresAt)
+    );
+
+create table if not exists product_reservations (
+    id char(36) not null,
+    productId varchar(40) not null,
+    storage varchar(45) not null default '',
+    quantity int not null,
+    status varchar(20) not null,
+    actor varchar(120) not null,
+    createdAt datetime(6) not null,
+    expiresAt datetime(6) not null,
+    updatedAt datetime(6) null,
+    primary key (id),
+    index idx_product_reservations_product (productId, status),
+    index idx_product_reservations_expires (status, expiresAt)
+    );
+
+create table if not exists stock_movements (
+    id bigint not null auto_increment,
+    productId varchar(40) not null,
+    storage varchar(45) not null default '',
+    delta int not null,
+    balance int not null,
+    reason varchar(20) not null,
+    reference varchar(120) null,
+    actor varchar(120) not null,
+    movedAt datetime(6) not null,
+    primary key (id),
+    index idx_stock_movements_product (productId, movedAt)
+    );
+
+create table if not exists product_prices (
+    id varchar(40) not null,
+    productId varchar(40) not null,
+    price bigint not null,
+    effectiveFrom datetime(6) not null,
+    effectiveTo datetime(6) null,
+    priority int not null default 0,
+    actor varchar(120) not null,
+    createdAt datetime(6) not null,
+    primary key (id),
+    index idx_product_prices_product (productId, effectiveFrom)
+    );
+
+create table if not exists price_history (
+    id bigint not null auto_increment,
+    productId varchar(40) not null,
+    price bigint not null,
+    previousPrice bigint null,
+    scheduleId varchar(40) null,
+    actor varchar(120) not null,
+    changedAt datetime(6) not null,
+    primary key (id),
+    index idx_price_history_product (productId, changedAt)
+    );
+
+create table if not exists price_failures (
+    productId varchar(40) not null,
+    attempts int not null default 0,
+    lastError varchar(1000) null,
+    nextAttemptAt datetime(6) not null,
+    primary key (productId)
+    );
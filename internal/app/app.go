@@ -4,22 +4,23 @@ import (
 	"context"
 	"github.com/core-go/health"
 	"github.com/core-go/log"
-	"github.com/core-go/search/query"
 	q "github.com/core-go/sql"
 	_ "github.com/go-sql-driver/mysql"
-	"reflect"
+	"net/http"
+	"time"
 
 	"go-service/internal/usecase/product/adapter/handler"
+	"go-service/internal/usecase/product/adapter/publisher"
 	"go-service/internal/usecase/product/adapter/repository"
-	. "go-service/internal/usecase/product/domain"
 	// . "go-service/internal/client"
 	. "go-service/internal/usecase/product/port"
 	. "go-service/internal/usecase/product/service"
 )
 
 type ApplicationContext struct {
-	Health  *health.Handler
-	product ProductHandler
+	Health      *health.Handler
+	Idempotency func(http.Handler) http.Handler
+	product     ProductHandler
 }
 
 func NewApp(ctx context.Context, conf Config) (*ApplicationContext, error) {
@@ -29,22 +30,67 @@ func NewApp(ctx context.Context, conf Config) (*ApplicationContext, error) {
 	}
 	logError := log.ErrorMsg
 
-	productType := reflect.TypeOf(Product{})
-	productQueryBuilder := query.NewBuilder(db, "products", productType)
-	productSearchBuilder, err := q.NewSearchBuilder(db, productType, productQueryBuilder.BuildQuery)
+	productSearchBuilder := repository.NewProductSearchBuilder(db, conf.Sql.Driver)
+
+	productRepository := repository.NewProductAdapter(db, conf.Sql.Driver, conf.SoftDelete.Enabled)
+	productAuditRepository := repository.NewProductAuditAdapter(db)
+	outboxRepository := repository.NewOutboxAdapter(db)
+	reservationRepository := repository.NewReservationAdapter(db)
+	movementRepository := repository.NewStockMovementAdapter(db)
+	priceRepository := repository.NewPriceAdapter(db)
+	reservationTTL := conf.Reservation.TTL
+	if reservationTTL <= 0 {
+		reservationTTL = 15 * time.Minute
+	}
+	lifecycle, err := NewLifecycle(conf.Lifecycle)
 	if err != nil {
 		return nil, err
 	}
+	validator, err := NewProductValidator()
+	if err != nil {
+		return nil, err
+	}
+	productService := NewProductService(repository.NewTxManager(db), productRepository, productAuditRepository, outboxRepository, reservationRepository, movementRepository, priceRepository, validator, lifecycle, reservationTTL)
+	if conf.SoftDelete.Enabled && conf.SoftDelete.Retention > 0 && conf.SoftDelete.PurgeInterval > 0 {
+		purgeJob := NewPurgeJob(productService, conf.SoftDelete.Retention, conf.SoftDelete.PurgeInterval, logError)
+		go purgeJob.Run(ctx)
+	}
+	if conf.Reservation.SweepInterval > 0 {
+		reservationSweeper := NewReservationSweeper(productService, conf.Reservation, logError)
+		go reservationSweeper.Run(ctx)
+	}
+	if conf.Price.MaterializeInterval > 0 {
+		priceMaterializer := NewPriceMaterializer(productService, conf.Price, logError)
+		go priceMaterializer.Run(ctx)
+	}
+	// without a webhook, the relay is not started, so the events stay pending in the outbox until one is configured
+	if conf.Outbox.Relay.Interval > 0 && len(conf.Outbox.Webhook.Url) > 0 {
+		outboxRelay := NewOutboxRelay(outboxRepository, publisher.NewWebhookPublisher(conf.Outbox.Webhook), conf.Outbox.Relay, logError)
+		go outboxRelay.Run(ctx)
+	}
+
+	idempotencyRepository := repository.NewIdempotencyAdapter(db)
+	idempotencyTTL := conf.Idempotency.TTL
+	if idempotencyTTL <= 0 {
+		idempotencyTTL = 24 * time.Hour
+	}
+	idempotencyLease := conf.Idempotency.Lease
+	if idempotencyLease <= 0 {
+		idempotencyLease = time.Minute
+	}
+	if conf.Idempotency.PurgeInterval > 0 {
+		idempotencyPurgeJob := NewIdempotencyPurgeJob(idempotencyRepository, conf.Idempotency.PurgeInterval, logError)
+		go idempotencyPurgeJob.Run(ctx)
+	}
 
-	productRepository := repository.NewProductAdapter(db)
-	productService := NewProductService(db, productRepository)
-	productHandler := handler.NewProductHandler(productSearchBuilder.Search, productService, logError)
+	productHandler := handler.NewProductHandler(productSearchBuilder.Search, productSearchBuilder.Facets, productService, logError)
 
 	sqlChecker := q.NewHealthChecker(db)
 	healthHandler := health.NewHandler(sqlChecker)
 
 	return &ApplicationContext{
-		Health:  healthHandler,
-		product: productHandler,
+		Health:      healthHandler,
+		Idempotency: handler.Idempotency(idempotencyRepository, idempotencyTTL, idempotencyLease, logError),
+		product:     productHandler,
 	}, nil
 }
@@ -6,12 +6,41 @@ import (
 	mid "github.com/core-go/log/middleware"
 	sv "github.com/core-go/service"
 	"github.com/core-go/sql"
+	"time"
+
+	"go-service/internal/usecase/product/adapter/handler"
+	"go-service/internal/usecase/product/adapter/publisher"
+	"go-service/internal/usecase/product/service"
 )
 
 type Config struct {
-	Server     sv.ServerConf       `mapstructure:"server"`
-	Sql        sql.Config          `mapstructure:"sql"`
-	Client     client.ClientConfig `mapstructure:"client"`
-	Log        log.Config          `mapstructure:"log"`
-	MiddleWare mid.LogConfig       `mapstructure:"middleware"`
+	Server      sv.ServerConf             `mapstructure:"server"`
+	Sql         sql.Config                `mapstructure:"sql"`
+	Client      client.ClientConfig       `mapstructure:"client"`
+	Log         log.Config                `mapstructure:"log"`
+	MiddleWare  mid.LogConfig             `mapstructure:"middleware"`
+	Actor       handler.ActorConfig       `mapstructure:"actor"`
+	SoftDelete  SoftDeleteConfig          `mapstructure:"soft_delete"`
+	Outbox      OutboxConfig              `mapstructure:"outbox"`
+	Idempotency IdempotencyConfig         `mapstructure:"idempotency"`
+	Reservation service.ReservationConfig `mapstructure:"reservation"`
+	Lifecycle   service.LifecycleConfig   `mapstructure:"lifecycle"`
+	Price       service.PriceConfig       `mapstructure:"price"`
+}
+
+type SoftDeleteConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	Retention     time.Duration `mapstructure:"retention"`
+	PurgeInterval time.Duration `mapstructure:"purge_interval"`
+}
+
+type OutboxConfig struct {
+	Relay   service.OutboxRelayConfig `mapstructure:"relay"`
+	Webhook publisher.WebhookConfig   `mapstructure:"webhook"`
+}
+
+type IdempotencyConfig struct {
+	TTL           time.Duration `mapstructure:"ttl"`
+	Lease         time.Duration `mapstructure:"lease"`
+	PurgeInterval time.Duration `mapstructure:"purge_interval"`
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	. "github.com/core-go/service"
 	"github.com/gorilla/mux"
+
+	"go-service/internal/usecase/product/adapter/handler"
 )
 
 func Route(r *mux.Router, ctx context.Context, conf Config) error {
@@ -12,14 +14,34 @@ func Route(r *mux.Router, ctx context.Context, conf Config) error {
 		return err
 	}
 	r.HandleFunc("/health", app.Health.Check).Methods(GET)
+	r.Use(handler.BuildActor(conf.Actor))
+	r.Use(app.Idempotency)
 
 	product := "/products"
 	r.HandleFunc(product+"/search", app.product.Search).Methods(GET, POST)
+	r.HandleFunc(product+"/batch", app.product.CreateBatch).Methods(POST)
+	r.HandleFunc(product+"/batch", app.product.UpsertBatch).Methods(PUT)
+	r.HandleFunc(product+"/batch", app.product.DeleteBatch).Methods(DELETE)
+	r.HandleFunc(product+"/export", app.product.Export).Methods(GET)
+	r.HandleFunc(product+"/import", app.product.Import).Methods(POST)
 	r.HandleFunc(product+"/{id}", app.product.Load).Methods(GET)
 	r.HandleFunc(product, app.product.Create).Methods(POST)
 	r.HandleFunc(product+"/{id}", app.product.Update).Methods(PUT)
 	r.HandleFunc(product+"/{id}", app.product.Patch).Methods(PATCH)
 	r.HandleFunc(product+"/{id}", app.product.Delete).Methods(DELETE)
+	r.HandleFunc(product+"/{id}/restore", app.product.Restore).Methods(POST)
+	r.HandleFunc(product+"/{id}/transitions", app.product.Transition).Methods(POST)
+	r.HandleFunc(product+"/{id}/history", app.product.History).Methods(GET)
+	r.HandleFunc(product+"/{id}/stocks", app.product.Stocks).Methods(GET)
+	r.HandleFunc(product+"/{id}/stocks/{storage}", app.product.SetStock).Methods(PUT)
+	r.HandleFunc(product+"/{id}/stock-movements", app.product.MoveStock).Methods(POST)
+	r.HandleFunc(product+"/{id}/stock-movements", app.product.StockMovements).Methods(GET)
+	r.HandleFunc(product+"/{id}/prices", app.product.SchedulePrice).Methods(POST)
+	r.HandleFunc(product+"/{id}/prices", app.product.ScheduledPrices).Methods(GET)
+	r.HandleFunc(product+"/{id}/price-history", app.product.PriceHistory).Methods(GET)
+	r.HandleFunc(product+"/{id}/reservations", app.product.Reserve).Methods(POST)
+	r.HandleFunc(product+"/{id}/reservations/{reservationId}/confirm", app.product.ConfirmReservation).Methods(POST)
+	r.HandleFunc(product+"/{id}/reservations/{reservationId}", app.product.ReleaseReservation).Methods(DELETE)
 
 	return nil
 }
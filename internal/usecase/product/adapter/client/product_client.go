@@ -2,10 +2,12 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/core-go/client"
 	. "go-service/internal/usecase/product/domain"
 	"net/http"
+	"strconv"
 )
 
 type ProductClient struct {
@@ -15,18 +17,6 @@ type ProductClient struct {
 	Log    func(context.Context, string, map[string]interface{})
 }
 
-type ResultInfo struct {
-	Status  int64          `mapstructure:"status" json:"status" gorm:"column:status" bson:"status" dynamodbav:"status" firestore:"status"`
-	Errors  []ErrorMessage `mapstructure:"errors" json:"errors,omitempty" gorm:"column:errors" bson:"errors,omitempty" dynamodbav:"errors,omitempty" firestore:"errors,omitempty"`
-	Message string         `mapstructure:"message" json:"message,omitempty" gorm:"column:message" bson:"message,omitempty" dynamodbav:"message,omitempty" firestore:"message,omitempty"`
-}
-type ErrorMessage struct {
-	Field   string `mapstructure:"field" json:"field,omitempty" gorm:"column:field" bson:"field,omitempty" dynamodbav:"field,omitempty" firestore:"field,omitempty"`
-	Code    string `mapstructure:"code" json:"code,omitempty" gorm:"column:code" bson:"code,omitempty" dynamodbav:"code,omitempty" firestore:"code,omitempty"`
-	Param   string `mapstructure:"param" json:"param,omitempty" gorm:"column:param" bson:"param,omitempty" dynamodbav:"param,omitempty" firestore:"param,omitempty"`
-	Message string `mapstructure:"message" json:"message,omitempty" gorm:"column:message" bson:"message,omitempty" dynamodbav:"message,omitempty" firestore:"message,omitempty"`
-}
-
 func NewProductClient(config client.ClientConfig, log func(context.Context, string, map[string]interface{})) (*ProductClient, error) {
 	c, _, conf, err := client.InitializeClient(config)
 	if err != nil {
@@ -62,9 +52,27 @@ func (c *ProductClient) Patch(ctx context.Context, Product map[string]interface{
 	return res.Status, err
 }
 
-func (c *ProductClient) Delete(ctx context.Context, id string) (int64, error) {
+// Delete removes a product; when version is positive it is sent as If-Match, so a stale copy is not deleted.
+func (c *ProductClient) Delete(ctx context.Context, id string, version int64) (int64, error) {
 	url := c.Url + "/" + id
 	var res int64
-	err := client.Delete(ctx, c.Client, url, &res, c.Config, c.Log)
+	if version <= 0 {
+		err := client.Delete(ctx, c.Client, url, &res, c.Config, c.Log)
+		return res, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return -1, err
+	}
+	req.Header.Set("If-Match", strconv.Quote(strconv.FormatInt(version, 10)))
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return -1, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return -1, fmt.Errorf("delete %s: %s", url, resp.Status)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&res)
 	return res, err
 }
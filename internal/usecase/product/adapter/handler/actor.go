@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+const (
+	ActorHeader       = "X-User-Id"
+	codeUnauthorized  = "unauthorized"
+	bearerTokenPrefix = "Bearer "
+)
+
+// ActorConfig tells how the user of a request is authenticated. A request sends one of the API keys as a bearer token.
+// The X-User-Id header is not authenticated, so it is used only when TrustHeader is set, behind a gateway which
+// authenticates the user and sets the header.
+type ActorConfig struct {
+	APIKeys     []APIKey `mapstructure:"api_keys"`
+	TrustHeader bool     `mapstructure:"trust_header"`
+}
+
+// APIKey is a key which authenticates a user.
+type APIKey struct {
+	User string `mapstructure:"user"`
+	Key  string `mapstructure:"key"`
+}
+
+// BuildActor returns a middleware which stores the authenticated user of a request in its context. A request with an
+// unknown API key is answered with 401; a request without a user is served as Anonymous.
+func BuildActor(conf ActorConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			actor := ""
+			if authorization := r.Header.Get("Authorization"); len(authorization) > 0 {
+				user, ok := authenticate(conf.APIKeys, authorization)
+				if !ok {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+					JSON(w, http.StatusUnauthorized, ErrorResponse{Code: codeUnauthorized, Message: "invalid API key"})
+					return
+				}
+				actor = user
+			} else if conf.TrustHeader {
+				actor = r.Header.Get(ActorHeader)
+			}
+			if len(actor) > 0 {
+				r = r.WithContext(WithActor(r.Context(), actor))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// authenticate returns the user of the bearer token of an Authorization header. All the keys are compared in
+// constant time, so the time of the comparison does not tell which key is close to the token.
+func authenticate(keys []APIKey, authorization string) (string, bool) {
+	if !strings.HasPrefix(authorization, bearerTokenPrefix) {
+		return "", false
+	}
+	token := []byte(strings.TrimPrefix(authorization, bearerTokenPrefix))
+	user := ""
+	for _, key := range keys {
+		if len(key.Key) > 0 && subtle.ConstantTimeCompare([]byte(key.Key), token) == 1 {
+			user = key.User
+		}
+	}
+	return user, len(user) > 0
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+const (
+	codeBadRequest           = "bad_request"
+	codeInternal             = "internal_error"
+	codeUnsupportedMediaType = "unsupported_media_type"
+)
+
+type ErrorResponse struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message,omitempty"`
+	Errors  []ErrorMessage `json:"errors,omitempty"`
+}
+
+// RespondError translates a domain error into its HTTP status and JSON body.
+// Unknown errors are logged and answered with 500 without leaking details.
+func RespondError(w http.ResponseWriter, r *http.Request, err error, logError func(context.Context, string)) {
+	var notFound *NotFoundError
+	var conflict *ConflictError
+	var validation *ValidationError
+	var versionMismatch *VersionMismatchError
+	switch {
+	case errors.As(err, &notFound):
+		JSON(w, http.StatusNotFound, ErrorResponse{Code: CodeNotFound, Message: notFound.Error()})
+	case errors.As(err, &conflict):
+		JSON(w, http.StatusConflict, ErrorResponse{Code: conflict.Code, Message: conflict.Error()})
+	case errors.As(err, &validation):
+		JSON(w, http.StatusUnprocessableEntity, ErrorResponse{Code: CodeValidation, Message: validation.Error(), Errors: validation.Errors})
+	case errors.As(err, &versionMismatch):
+		JSON(w, http.StatusPreconditionFailed, ErrorResponse{Code: CodeVersionMismatch, Message: versionMismatch.Error()})
+	default:
+		if logError != nil {
+			logError(r.Context(), err.Error())
+		}
+		JSON(w, http.StatusInternalServerError, ErrorResponse{Code: codeInternal, Message: http.StatusText(http.StatusInternalServerError)})
+	}
+}
+
+func badRequest(w http.ResponseWriter, message string) {
+	JSON(w, http.StatusBadRequest, ErrorResponse{Code: codeBadRequest, Message: message})
+}
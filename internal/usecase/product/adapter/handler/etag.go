@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// ETag formats a product version as a strong entity tag.
+func ETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// IfMatch returns the version required by the If-Match header, AnyVersion for "*", which requires the product to exist,
+// or 0 when the write is unconditional. If-Match compares entity tags strongly, so a weak tag, which never matches,
+// is rejected.
+func IfMatch(r *http.Request) (int64, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if len(header) == 0 {
+		return 0, nil
+	}
+	if header == "*" {
+		return AnyVersion, nil
+	}
+	if strings.HasPrefix(header, "W/") {
+		return 0, errors.New("If-Match must be a strong ETag")
+	}
+	unquoted, err := strconv.Unquote(header)
+	if err != nil {
+		unquoted = header
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, errors.New("If-Match must be an ETag returned by this service")
+	}
+	return version, nil
+}
@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	. "go-service/internal/usecase/product/domain"
+	. "go-service/internal/usecase/product/port"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	ReplayedHeader       = "Idempotent-Replayed"
+
+	codeIdempotencyKeyReused     = "idempotency_key_reused"
+	codeIdempotencyKeyInProgress = "idempotency_key_in_progress"
+	codePayloadTooLarge          = "payload_too_large"
+
+	maxIdempotencyKey  = 255
+	maxReplayedBody    = 1 << 20
+	maxBufferedInput   = 1 << 20
+	maxIdempotentInput = 64 << 20
+)
+
+var errPayloadTooLarge = fmt.Errorf("the body cannot be larger than %d bytes", maxIdempotentInput)
+
+// replayedHeaders are the response headers which are stored with the response, to be replayed.
+var replayedHeaders = []string{"Content-Type", "Content-Disposition", "Location", "ETag"}
+
+// Idempotency is a middleware which makes a POST with an Idempotency-Key header run once per key and user:
+// the response is stored for ttl, and replayed when the request is retried with the same key and the same body.
+// The same key with another request is answered with 422, and with 409 while the first request is in progress.
+// Responses with a 5xx status, or too large to be stored, are not stored, so that the request can be retried.
+// A request in progress holds its key for lease, and renews it while it runs; a retry takes the key over only
+// when the lease is over, because the first request was abandoned before its response was stored.
+func Idempotency(repository IdempotencyRepository, ttl time.Duration, lease time.Duration, logError func(context.Context, string)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if r.Method != http.MethodPost || len(key) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKey {
+				badRequest(w, fmt.Sprintf("%s cannot be longer than %d characters", IdempotencyKeyHeader, maxIdempotencyKey))
+				return
+			}
+			body, hash, err := spoolBody(r)
+			r.Body.Close()
+			if err == errPayloadTooLarge {
+				JSON(w, http.StatusRequestEntityTooLarge, ErrorResponse{Code: codePayloadTooLarge, Message: err.Error()})
+				return
+			}
+			if err != nil {
+				badRequest(w, err.Error())
+				return
+			}
+			defer body.Close()
+			r.Body = body
+
+			owner, err := newOwner()
+			if err != nil {
+				RespondError(w, r, err, logError)
+				return
+			}
+			now := time.Now()
+			record := &IdempotencyRecord{
+				Actor:       ActorFromContext(r.Context()),
+				Key:         key,
+				RequestHash: hash,
+				Owner:       owner,
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(ttl),
+				LockedUntil: now.Add(lease),
+			}
+			existing, err := repository.Begin(r.Context(), record)
+			if err != nil {
+				RespondError(w, r, err, logError)
+				return
+			}
+			if existing != nil {
+				replay(w, existing, record.RequestHash)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+			stop := renewLease(repository, *record, lease, logError)
+			next.ServeHTTP(recorder, r)
+			stop()
+
+			// the request context may be cancelled once the response is sent
+			ctx := context.Background()
+			if recorder.statusCode >= http.StatusInternalServerError || recorder.overflow {
+				err = repository.Remove(ctx, record)
+			} else {
+				record.StatusCode = recorder.statusCode
+				record.Header = make(map[string]string)
+				for _, name := range replayedHeaders {
+					if v := recorder.Header().Get(name); len(v) > 0 {
+						record.Header[name] = v
+					}
+				}
+				record.Body = recorder.body.Bytes()
+				if err = repository.Complete(ctx, record); err != nil {
+					// the key is released rather than left in progress until its lease is over, so the request can be retried
+					if er2 := repository.Remove(ctx, record); er2 != nil {
+						err = fmt.Errorf("%s; cannot release the key: %s", err.Error(), er2.Error())
+					}
+				}
+			}
+			if err != nil && logError != nil {
+				logError(r.Context(), fmt.Sprintf("cannot store the response of %s '%s': %s", IdempotencyKeyHeader, key, err.Error()))
+			}
+		})
+	}
+}
+
+// renewLease extends the lock of a record in progress every third of its lease, until stop is called,
+// so that a request which takes longer than its lease keeps its key.
+func renewLease(repository IdempotencyRepository, record IdempotencyRecord, lease time.Duration, logError func(context.Context, string)) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				record.LockedUntil = now.Add(lease)
+				if err := repository.Renew(context.Background(), &record); err != nil && logError != nil {
+					logError(context.Background(), fmt.Sprintf("cannot renew the lease of %s '%s': %s", IdempotencyKeyHeader, record.Key, err.Error()))
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+func newOwner() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+func replay(w http.ResponseWriter, record *IdempotencyRecord, requestHash string) {
+	switch {
+	case record.RequestHash != requestHash:
+		JSON(w, http.StatusUnprocessableEntity, ErrorResponse{Code: codeIdempotencyKeyReused, Message: fmt.Sprintf("%s '%s' has been used for another request", IdempotencyKeyHeader, record.Key)})
+	case record.InProgress():
+		w.Header().Set("Retry-After", "1")
+		JSON(w, http.StatusConflict, ErrorResponse{Code: codeIdempotencyKeyInProgress, Message: fmt.Sprintf("a request with %s '%s' is in progress", IdempotencyKeyHeader, record.Key)})
+	default:
+		for name, v := range record.Header {
+			w.Header().Set(name, v)
+		}
+		w.Header().Set(ReplayedHeader, "true")
+		w.WriteHeader(record.StatusCode)
+		w.Write(record.Body)
+	}
+}
+
+// spoolBody reads the body of a request, up to maxIdempotentInput bytes, so that it can be read again by the handler,
+// and hashes it with the method, the path and the query of the request. A body up to maxBufferedInput bytes is kept
+// in memory, and a larger one in a temporary file, which is removed when the returned body is closed.
+func spoolBody(r *http.Request) (io.ReadCloser, string, error) {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	var buffer bytes.Buffer
+	n, err := io.Copy(io.MultiWriter(&buffer, h), io.LimitReader(r.Body, maxBufferedInput+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if n <= maxBufferedInput {
+		return ioutil.NopCloser(&buffer), hex.EncodeToString(h.Sum(nil)), nil
+	}
+	file, err := ioutil.TempFile("", "idempotent-body-")
+	if err != nil {
+		return nil, "", err
+	}
+	body := &tempFileBody{file: file}
+	// the buffered bytes are hashed already
+	buffered := n
+	if _, err = buffer.WriteTo(file); err == nil {
+		n, err = io.Copy(io.MultiWriter(file, h), io.LimitReader(r.Body, maxIdempotentInput+1-buffered))
+	}
+	if err == nil && buffered+n > maxIdempotentInput {
+		err = errPayloadTooLarge
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		body.Close()
+		return nil, "", err
+	}
+	return body, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// tempFileBody is a body spooled in a temporary file, which is removed when it is closed.
+type tempFileBody struct {
+	file   *os.File
+	closed bool
+}
+
+func (b *tempFileBody) Read(p []byte) (int, error) {
+	return b.file.Read(p)
+}
+
+func (b *tempFileBody) Close() error {
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	b.file.Close()
+	return os.Remove(b.file.Name())
+}
+
+// responseRecorder copies the response, up to maxReplayedBody bytes, while it is written.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+	overflow    bool
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.statusCode, r.wroteHeader = statusCode, true
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.wroteHeader = true
+	if !r.overflow {
+		if r.body.Len()+len(data) > maxReplayedBody {
+			r.overflow = true
+			r.body.Reset()
+		} else {
+			r.body.Write(data)
+		}
+	}
+	return r.ResponseWriter.Write(data)
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+type HistoryResult struct {
+	List  []ProductAudit `json:"list"`
+	Total int64          `json:"total"`
+}
+
+// paging reads the 1-based "page" and the "limit" query parameters, and returns the limit and the offset.
+func paging(r *http.Request) (int64, int64, error) {
+	query := r.URL.Query()
+	limit, page := int64(defaultLimit), int64(1)
+	if s := query.Get("limit"); len(s) > 0 {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, errors.New("limit must be a positive integer")
+		}
+		limit = n
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	if s := query.Get("page"); len(s) > 0 {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, errors.New("page must be a positive integer")
+		}
+		page = n
+	}
+	return limit, (page - 1) * limit, nil
+}
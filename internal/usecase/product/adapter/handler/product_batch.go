@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+const maxBatchSize = 10000
+
+func (h *HttpProductHandler) CreateBatch(w http.ResponseWriter, r *http.Request) {
+	products, atomic, ok := decodeProducts(w, r)
+	if !ok {
+		return
+	}
+	results, err := h.service.CreateBatch(r.Context(), products, atomic)
+	h.respondBatch(w, r, results, err)
+}
+
+func (h *HttpProductHandler) UpsertBatch(w http.ResponseWriter, r *http.Request) {
+	products, atomic, ok := decodeProducts(w, r)
+	if !ok {
+		return
+	}
+	results, err := h.service.UpsertBatch(r.Context(), products, atomic)
+	h.respondBatch(w, r, results, err)
+}
+
+func (h *HttpProductHandler) DeleteBatch(w http.ResponseWriter, r *http.Request) {
+	var ids []string
+	seen := make(map[string]bool)
+	atomic, ok := decodeBatch(w, r, func(decoder *json.Decoder) error {
+		var id string
+		if err := decoder.Decode(&id); err != nil {
+			return err
+		}
+		if err := checkDuplicate(seen, id); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+		return nil
+	})
+	if !ok {
+		return
+	}
+	results, err := h.service.DeleteBatch(r.Context(), ids, atomic)
+	h.respondBatch(w, r, results, err)
+}
+
+func decodeProducts(w http.ResponseWriter, r *http.Request) ([]Product, bool, bool) {
+	var products []Product
+	seen := make(map[string]bool)
+	atomic, ok := decodeBatch(w, r, func(decoder *json.Decoder) error {
+		var product Product
+		if err := decoder.Decode(&product); err != nil {
+			return err
+		}
+		if err := checkDuplicate(seen, product.GeneralInfo.Id); err != nil {
+			return err
+		}
+		products = append(products, product)
+		return nil
+	})
+	return products, atomic, ok
+}
+
+// decodeBatch reads a json array from the body, item by item with decode, and the "mode" query parameter:
+// "atomic" (default) writes all items or none, "best-effort" writes the items which succeed.
+func decodeBatch(w http.ResponseWriter, r *http.Request, decode func(decoder *json.Decoder) error) (bool, bool) {
+	var atomic bool
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "", "atomic":
+		atomic = true
+	case "best-effort":
+		atomic = false
+	default:
+		badRequest(w, fmt.Sprintf("mode must be 'atomic' or 'best-effort', not '%s'", mode))
+		return false, false
+	}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		badRequest(w, "the body must be a json array")
+		return false, false
+	}
+	for count := 0; decoder.More(); count++ {
+		if count == maxBatchSize {
+			badRequest(w, fmt.Sprintf("a batch cannot have more than %d items", maxBatchSize))
+			return false, false
+		}
+		if err := decode(decoder); err != nil {
+			badRequest(w, err.Error())
+			return false, false
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		badRequest(w, err.Error())
+		return false, false
+	}
+	return atomic, true
+}
+
+// checkDuplicate rejects an id which is already in the batch, since the result of each item is told by its id.
+func checkDuplicate(seen map[string]bool, id string) error {
+	if len(id) == 0 {
+		return nil
+	}
+	if seen[id] {
+		return fmt.Errorf("'%s' is more than once in the batch", id)
+	}
+	seen[id] = true
+	return nil
+}
+
+// respondBatch answers the results of the items, in the order of the request.
+// An atomic batch with invalid items is answered with 422 and the results, so the client sees which items failed.
+func (h *HttpProductHandler) respondBatch(w http.ResponseWriter, r *http.Request, results []ResultInfo, err error) {
+	if err != nil {
+		var validation *ValidationError
+		if results != nil && errors.As(err, &validation) {
+			JSON(w, http.StatusUnprocessableEntity, results)
+			return
+		}
+		RespondError(w, r, err, h.logError)
+		return
+	}
+	JSON(w, http.StatusOK, results)
+}
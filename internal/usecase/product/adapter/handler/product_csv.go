@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// productCsvHeader is the header of exported files; imported files may have these columns in any order.
+var productCsvHeader = []string{"id", "productName", "description", "price", "currency", "status", "available", "version", "supplier", "storage", "inStockAmount"}
+
+func productToCsv(product *Product) []string {
+	g, d := product.GeneralInfo, product.DetailInfo
+	return []string{
+		g.Id, g.ProductName, g.Description,
+		strconv.FormatInt(int64(g.Price), 10), string(g.Currency), string(g.Status), strconv.FormatBool(g.Available), strconv.FormatInt(g.Version, 10),
+		d.Supplier, d.Storage, strconv.Itoa(d.InStockAmount),
+	}
+}
+
+// readProductCsv reads products from a csv file with a header line, calling add with the line number of each row.
+// A row which cannot be converted is reported to fail instead.
+func readProductCsv(r io.Reader, add func(line int, product Product), fail func(line int, e ErrorMessage)) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("cannot read csv header: %s", err.Error())
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.TrimSpace(name)] = i
+	}
+	if _, ok := index["id"]; !ok {
+		return fmt.Errorf("csv header must have an 'id' column")
+	}
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		line++
+		if err != nil {
+			fail(line, ErrorMessage{Code: "format", Message: err.Error()})
+			continue
+		}
+		get := func(column string) string {
+			if i, ok := index[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		var product Product
+		product.GeneralInfo.Id = get("id")
+		product.GeneralInfo.ProductName = get("productName")
+		product.GeneralInfo.Description = get("description")
+		product.GeneralInfo.Currency = Currency(strings.ToUpper(get("currency")))
+		product.GeneralInfo.Status = ProductStatus(get("status"))
+		product.DetailInfo.Supplier = get("supplier")
+		product.DetailInfo.Storage = get("storage")
+		ok := true
+		if s := get("price"); len(s) > 0 {
+			if err = product.GeneralInfo.Price.Scan(s); err != nil {
+				fail(line, ErrorMessage{Field: "price", Code: "type", Message: "price must be an integer amount in minor units"})
+				ok = false
+			}
+		}
+		if s := get("inStockAmount"); len(s) > 0 {
+			if product.DetailInfo.InStockAmount, err = strconv.Atoi(s); err != nil {
+				fail(line, ErrorMessage{Field: "inStockAmount", Code: "type", Message: "inStockAmount must be an integer"})
+				ok = false
+			}
+		}
+		if ok {
+			add(line, product)
+		}
+	}
+}
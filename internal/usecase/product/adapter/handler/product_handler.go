@@ -3,133 +3,236 @@ package handler
 import (
 	"context"
 	"encoding/json"
-	"github.com/core-go/search"
-	sv "github.com/core-go/service"
 	"github.com/gorilla/mux"
+	"mime"
 	"net/http"
-	"reflect"
 
 	. "go-service/internal/usecase/product/domain"
 	. "go-service/internal/usecase/product/service"
 )
 
-func NewProductHandler(find func(context.Context, interface{}, interface{}, int64, ...int64) (int64, string, error), service ProductService, logError func(context.Context, string)) *HttpProductHandler {
-	filterType := reflect.TypeOf(ProductFilter{})
-	modelType := reflect.TypeOf(Product{})
-	searchHandler := search.NewSearchHandler(find, modelType, filterType, logError, nil)
-	return &HttpProductHandler{service: service, SearchHandler: searchHandler}
+const (
+	contentTypeJSONPatch  = "application/json-patch+json"
+	contentTypeMergePatch = "application/merge-patch+json"
+)
+
+func NewProductHandler(find func(context.Context, interface{}, interface{}, int64, ...int64) (int64, string, error), facets func(context.Context, *ProductFilter, []string) (map[string][]FacetCount, error), service ProductService, logError func(context.Context, string)) *HttpProductHandler {
+	return &HttpProductHandler{service: service, find: find, facets: facets, logError: logError}
 }
 
 type HttpProductHandler struct {
-	service ProductService
-	*search.SearchHandler
+	service  ProductService
+	find     func(context.Context, interface{}, interface{}, int64, ...int64) (int64, string, error)
+	facets   func(context.Context, *ProductFilter, []string) (map[string][]FacetCount, error)
+	logError func(context.Context, string)
 }
 
 func (h *HttpProductHandler) Load(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	if len(id) == 0 {
-		http.Error(w, "Id cannot be empty", http.StatusBadRequest)
+		badRequest(w, "Id cannot be empty")
 		return
 	}
 
-	product, err := h.service.Load(r.Context(), id)
+	projection, err := productProjectionFromQuery(r.URL.Query())
+	if err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+
+	var product *Product
+	if projection == nil {
+		product, err = h.service.Load(r.Context(), id)
+	} else {
+		product, err = h.service.LoadProjection(r.Context(), id, projection)
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		RespondError(w, r, err, h.logError)
 		return
 	}
-	JSON(w, http.StatusOK, product)
+	view, err := viewProduct(product, projection)
+	if err != nil {
+		RespondError(w, r, err, h.logError)
+		return
+	}
+	w.Header().Set("ETag", ETag(product.GeneralInfo.Version))
+	JSON(w, http.StatusOK, view)
 }
 func (h *HttpProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var product Product
 	er1 := json.NewDecoder(r.Body).Decode(&product)
 	defer r.Body.Close()
 	if er1 != nil {
-		http.Error(w, er1.Error(), http.StatusBadRequest)
+		badRequest(w, er1.Error())
 		return
 	}
 
 	res, er2 := h.service.Create(r.Context(), &product)
 	if er2 != nil {
-		http.Error(w, er1.Error(), http.StatusInternalServerError)
+		RespondError(w, r, er2, h.logError)
 		return
 	}
 	JSON(w, http.StatusCreated, res)
 }
+
+// Update creates or replaces a product: it responds 201 when the product did not exist, and 200 when it was replaced.
 func (h *HttpProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 	var product Product
 	er1 := json.NewDecoder(r.Body).Decode(&product)
 	defer r.Body.Close()
 	if er1 != nil {
-		http.Error(w, er1.Error(), http.StatusBadRequest)
+		badRequest(w, er1.Error())
 		return
 	}
 	id := mux.Vars(r)["id"]
 	if len(id) == 0 {
-		http.Error(w, "Id cannot be empty", http.StatusBadRequest)
+		badRequest(w, "Id cannot be empty")
 		return
 	}
 	if len(product.GeneralInfo.Id) == 0 {
 		product.GeneralInfo.Id = id
 	} else if id != product.GeneralInfo.Id {
-		http.Error(w, "Id not match", http.StatusBadRequest)
+		badRequest(w, "Id not match")
+		return
+	}
+	version, er3 := IfMatch(r)
+	if er3 != nil {
+		badRequest(w, er3.Error())
 		return
 	}
+	if version != 0 {
+		product.GeneralInfo.Version = version
+	}
 
-	res, er2 := h.service.Update(r.Context(), &product)
+	created, er2 := h.service.Upsert(r.Context(), &product)
 	if er2 != nil {
-		http.Error(w, er2.Error(), http.StatusInternalServerError)
+		RespondError(w, r, er2, h.logError)
 		return
 	}
-	JSON(w, http.StatusOK, res)
+	w.Header().Set("ETag", ETag(product.GeneralInfo.Version))
+	if created {
+		w.Header().Set("Location", r.URL.Path)
+		JSON(w, http.StatusCreated, 1)
+		return
+	}
+	JSON(w, http.StatusOK, 1)
 }
+
+// Patch applies a JSON Patch (RFC 6902) to a product when the content type is application/json-patch+json,
+// or a JSON merge patch (RFC 7396) when it is application/merge-patch+json or application/json.
 func (h *HttpProductHandler) Patch(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	if len(id) == 0 {
-		http.Error(w, "Id cannot be empty", http.StatusBadRequest)
+		badRequest(w, "Id cannot be empty")
 		return
 	}
-
-	var product Product
-	productType := reflect.TypeOf(product)
-	_, jsonMap, _ := sv.BuildMapField(productType)
-	body, er1 := sv.BuildMapAndStruct(r, &product)
+	version, er1 := IfMatch(r)
 	if er1 != nil {
-		http.Error(w, er1.Error(), http.StatusInternalServerError)
+		badRequest(w, er1.Error())
 		return
 	}
-	if len(product.GeneralInfo.Id) == 0 {
-		product.GeneralInfo.Id = id
-	} else if id != product.GeneralInfo.Id {
-		http.Error(w, "Id not match", http.StatusBadRequest)
+	defer r.Body.Close()
+
+	var newVersion int64
+	var er2 error
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch contentType {
+	case contentTypeJSONPatch:
+		var operations []PatchOperation
+		if err := json.NewDecoder(r.Body).Decode(&operations); err != nil {
+			badRequest(w, err.Error())
+			return
+		}
+		newVersion, er2 = h.service.JSONPatch(r.Context(), id, version, operations)
+	case contentTypeMergePatch, "application/json", "":
+		var patch map[string]interface{}
+		decoder := json.NewDecoder(r.Body)
+		decoder.UseNumber()
+		if err := decoder.Decode(&patch); err != nil {
+			badRequest(w, err.Error())
+			return
+		}
+		if general, ok := patch["GeneralInfo"].(map[string]interface{}); ok {
+			if patchId, ok := general["id"]; ok && patchId != id {
+				badRequest(w, "Id not match")
+				return
+			}
+		}
+		if patchId, ok := patch["id"]; ok && patchId != id {
+			badRequest(w, "Id not match")
+			return
+		}
+		newVersion, er2 = h.service.Patch(r.Context(), id, version, patch)
+	default:
+		w.Header().Set("Accept-Patch", contentTypeJSONPatch+", "+contentTypeMergePatch)
+		JSON(w, http.StatusUnsupportedMediaType, ErrorResponse{Code: codeUnsupportedMediaType, Message: "Content-Type must be " + contentTypeJSONPatch + " or " + contentTypeMergePatch})
 		return
 	}
-	json, er2 := sv.BodyToJsonMap(r, product, body, []string{"id"}, jsonMap)
 	if er2 != nil {
-		http.Error(w, er2.Error(), http.StatusInternalServerError)
+		RespondError(w, r, er2, h.logError)
 		return
 	}
+	w.Header().Set("ETag", ETag(newVersion))
+	JSON(w, http.StatusOK, 1)
+}
 
-	res, er3 := h.service.Patch(r.Context(), json)
-	if er3 != nil {
-		http.Error(w, er3.Error(), http.StatusInternalServerError)
+func (h *HttpProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if len(id) == 0 {
+		badRequest(w, "Id cannot be empty")
+		return
+	}
+	version, err := IfMatch(r)
+	if err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+	res, err := h.service.Delete(r.Context(), id, version)
+	if err != nil {
+		RespondError(w, r, err, h.logError)
 		return
 	}
 	JSON(w, http.StatusOK, res)
 }
-func (h *HttpProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
+
+func (h *HttpProductHandler) Restore(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	if len(id) == 0 {
-		http.Error(w, "Id cannot be empty", http.StatusBadRequest)
+		badRequest(w, "Id cannot be empty")
 		return
 	}
-	res, err := h.service.Delete(r.Context(), id)
+	version, err := IfMatch(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		badRequest(w, err.Error())
+		return
+	}
+	res, err := h.service.Restore(r.Context(), id, version)
+	if err != nil {
+		RespondError(w, r, err, h.logError)
 		return
 	}
 	JSON(w, http.StatusOK, res)
 }
 
+func (h *HttpProductHandler) History(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if len(id) == 0 {
+		badRequest(w, "Id cannot be empty")
+		return
+	}
+	limit, offset, err := paging(r)
+	if err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+	list, total, err := h.service.History(r.Context(), id, limit, offset)
+	if err != nil {
+		RespondError(w, r, err, h.logError)
+		return
+	}
+	JSON(w, http.StatusOK, HistoryResult{List: list, Total: total})
+}
+
 func JSON(w http.ResponseWriter, code int, res interface{}) error {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// Transition moves a product to the status of a body like {"to": "active"}; If-Match makes it conditional on the version.
+func (h *HttpProductHandler) Transition(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if len(id) == 0 {
+		badRequest(w, "Id cannot be empty")
+		return
+	}
+	version, err := IfMatch(r)
+	if err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+	var transition ProductTransition
+	er1 := json.NewDecoder(r.Body).Decode(&transition)
+	defer r.Body.Close()
+	if er1 != nil {
+		badRequest(w, er1.Error())
+		return
+	}
+	res, er2 := h.service.Transition(r.Context(), id, version, transition)
+	if er2 != nil {
+		RespondError(w, r, er2, h.logError)
+		return
+	}
+	JSON(w, http.StatusOK, res)
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+type ScheduledPriceResult struct {
+	List  []ScheduledPrice `json:"list"`
+	Total int64            `json:"total"`
+}
+
+type PriceChangeResult struct {
+	List  []PriceChange `json:"list"`
+	Total int64         `json:"total"`
+}
+
+// SchedulePrice schedules a price of a product, from a body with its price, its effectiveFrom,
+// and optionally its effectiveTo and its priority.
+func (h *HttpProductHandler) SchedulePrice(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if len(id) == 0 {
+		badRequest(w, "Id cannot be empty")
+		return
+	}
+	var price ScheduledPrice
+	er1 := json.NewDecoder(r.Body).Decode(&price)
+	defer r.Body.Close()
+	if er1 != nil {
+		badRequest(w, er1.Error())
+		return
+	}
+	if er2 := h.service.SchedulePrice(r.Context(), id, &price); er2 != nil {
+		RespondError(w, r, er2, h.logError)
+		return
+	}
+	JSON(w, http.StatusCreated, price)
+}
+
+// ScheduledPrices lists the scheduled prices of a product, the latest to start first.
+func (h *HttpProductHandler) ScheduledPrices(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if len(id) == 0 {
+		badRequest(w, "Id cannot be empty")
+		return
+	}
+	limit, offset, err := paging(r)
+	if err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+	list, total, err := h.service.ScheduledPrices(r.Context(), id, limit, offset)
+	if err != nil {
+		RespondError(w, r, err, h.logError)
+		return
+	}
+	JSON(w, http.StatusOK, ScheduledPriceResult{List: list, Total: total})
+}
+
+// PriceHistory lists the prices a product has had in effect, most recent first.
+func (h *HttpProductHandler) PriceHistory(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if len(id) == 0 {
+		badRequest(w, "Id cannot be empty")
+		return
+	}
+	limit, offset, err := paging(r)
+	if err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+	list, total, err := h.service.PriceHistory(r.Context(), id, limit, offset)
+	if err != nil {
+		RespondError(w, r, err, h.logError)
+		return
+	}
+	JSON(w, http.StatusOK, PriceChangeResult{List: list, Total: total})
+}
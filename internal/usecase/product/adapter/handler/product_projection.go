@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// productFields are the json names of ProductGeneral, which "fields" may select.
+var productFields = jsonNames(reflect.TypeOf(ProductGeneral{}))
+
+func productProjectionFromQuery(query url.Values) (*ProductProjection, error) {
+	fields, include := queryList(query, "fields"), queryList(query, "include")
+	if err := checkProjection(fields, include); err != nil {
+		return nil, err
+	}
+	return NewProductProjection(fields, include), nil
+}
+
+func checkProjection(fields []string, include []string) error {
+	for _, field := range fields {
+		if !productFields[field] {
+			return fmt.Errorf("fields cannot have %q", field)
+		}
+	}
+	for _, s := range include {
+		if s != IncludeDetails {
+			return fmt.Errorf("include must be '%s', not %q", IncludeDetails, s)
+		}
+	}
+	return nil
+}
+
+// viewProduct returns a product with only what its projection selects, or the product itself when the projection is nil.
+func viewProduct(product *Product, projection *ProductProjection) (interface{}, error) {
+	if projection == nil {
+		return product, nil
+	}
+	view := make(map[string]interface{})
+	general, err := toMap(product.GeneralInfo)
+	if err != nil {
+		return nil, err
+	}
+	if len(projection.Fields) > 0 {
+		selected := make(map[string]interface{}, len(projection.Fields))
+		for _, field := range projection.Fields {
+			if v, ok := general[field]; ok {
+				selected[field] = v
+			}
+		}
+		general = selected
+	}
+	view["GeneralInfo"] = general
+	if projection.Details {
+		view["DetailInfo"] = product.DetailInfo
+	}
+	if product.Relevance != nil {
+		view["relevance"] = *product.Relevance
+	}
+	return view, nil
+}
+
+func viewProducts(products []Product, projection *ProductProjection) (interface{}, error) {
+	if projection == nil {
+		return products, nil
+	}
+	views := make([]interface{}, len(products))
+	for i := range products {
+		view, err := viewProduct(&products[i], projection)
+		if err != nil {
+			return nil, err
+		}
+		views[i] = view
+	}
+	return views, nil
+}
+
+func toMap(model interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+func jsonNames(modelType reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < modelType.NumField(); i++ {
+		if name := strings.Split(modelType.Field(i).Tag.Get("json"), ",")[0]; len(name) > 0 && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
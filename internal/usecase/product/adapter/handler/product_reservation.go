@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// Reserve holds a quantity of a product, from a body with its quantity and optionally its storage.
+func (h *HttpProductHandler) Reserve(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if len(id) == 0 {
+		badRequest(w, "Id cannot be empty")
+		return
+	}
+	var reservation Reservation
+	er1 := json.NewDecoder(r.Body).Decode(&reservation)
+	defer r.Body.Close()
+	if er1 != nil {
+		badRequest(w, er1.Error())
+		return
+	}
+	if er2 := h.service.Reserve(r.Context(), id, &reservation); er2 != nil {
+		RespondError(w, r, er2, h.logError)
+		return
+	}
+	w.Header().Set("Location", r.URL.Path+"/"+reservation.Id)
+	JSON(w, http.StatusCreated, reservation)
+}
+
+func (h *HttpProductHandler) ConfirmReservation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	reservation, err := h.service.ConfirmReservation(r.Context(), vars["id"], vars["reservationId"])
+	if err != nil {
+		RespondError(w, r, err, h.logError)
+		return
+	}
+	JSON(w, http.StatusOK, reservation)
+}
+
+func (h *HttpProductHandler) ReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	reservation, err := h.service.ReleaseReservation(r.Context(), vars["id"], vars["reservationId"])
+	if err != nil {
+		RespondError(w, r, err, h.logError)
+		return
+	}
+	JSON(w, http.StatusOK, reservation)
+}
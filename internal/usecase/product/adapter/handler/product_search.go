@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/core-go/search"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// SearchResult has the total of the matching products when paging by page index,
+// and the token of the next page when paging with a cursor. Facets are there when the filter asks for them.
+type SearchResult struct {
+	List          interface{}             `json:"list"`
+	Total         *int64                  `json:"total,omitempty"`
+	NextPageToken string                  `json:"nextPageToken,omitempty"`
+	Facets        map[string][]FacetCount `json:"facets,omitempty"`
+}
+
+// Search finds the products matching a filter, read from the json body of a POST or from the query string of a GET.
+func (h *HttpProductHandler) Search(w http.ResponseWriter, r *http.Request) {
+	var filter *ProductFilter
+	var err error
+	if r.Method == http.MethodPost {
+		filter, err = productFilterFromBody(r)
+	} else {
+		filter, err = productFilterFromQuery(r)
+	}
+	if err == nil {
+		err = checkProductFilter(filter)
+	}
+	if err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	} else if limit > maxLimit {
+		limit = maxLimit
+	}
+	page := filter.Page
+	if page <= 0 {
+		page = 1
+	}
+
+	list := make([]Product, 0)
+	total, nextPageToken, err := h.find(r.Context(), filter, &list, limit, (page-1)*limit)
+	if err != nil {
+		RespondError(w, r, err, h.logError)
+		return
+	}
+	views, err := viewProducts(list, filter.Projection())
+	if err != nil {
+		RespondError(w, r, err, h.logError)
+		return
+	}
+	result := SearchResult{List: views}
+	if filter.CursorPaging() {
+		result.NextPageToken = nextPageToken
+	} else {
+		result.Total = &total
+	}
+	if len(filter.Facets) > 0 {
+		if result.Facets, err = h.facets(r.Context(), filter, filter.Facets); err != nil {
+			RespondError(w, r, err, h.logError)
+			return
+		}
+	}
+	JSON(w, http.StatusOK, result)
+}
+
+func productFilterFromBody(r *http.Request) (*ProductFilter, error) {
+	var filter ProductFilter
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+		return nil, err
+	}
+	if filter.Filter == nil {
+		filter.Filter = &search.Filter{}
+	}
+	return &filter, nil
+}
+
+// productFilterFromQuery reads the product filter from the query string. Ranges are read from "name.min" and "name.max",
+// and status may be repeated or separated by commas.
+func productFilterFromQuery(r *http.Request) (*ProductFilter, error) {
+	query := r.URL.Query()
+	filter := &ProductFilter{
+		Filter:        &search.Filter{Sort: query.Get("sort"), Q: query.Get("q")},
+		Id:            query.Get("id"),
+		ProductName:   query.Get("productName"),
+		Description:   query.Get("description"),
+		Match:         TextMatch(query.Get("match")),
+		Supplier:      query.Get("supplier"),
+		Storage:       query.Get("storage"),
+		NextPageToken: query.Get("nextPageToken"),
+	}
+	var err error
+	if filter.Page, err = queryInt(query, "page"); err != nil {
+		return nil, err
+	}
+	if filter.Limit, err = queryInt(query, "limit"); err != nil {
+		return nil, err
+	}
+	for _, status := range queryList(query, "status") {
+		filter.Status = append(filter.Status, ProductStatus(status))
+	}
+	filter.Facets = queryList(query, "facets")
+	filter.Fields = queryList(query, "fields")
+	filter.Include = queryList(query, "include")
+	if filter.Price, err = queryRange(query, "price"); err != nil {
+		return nil, err
+	}
+	if filter.InStockAmount, err = queryRange(query, "inStockAmount"); err != nil {
+		return nil, err
+	}
+	if s := query.Get("available"); len(s) > 0 {
+		available, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("available must be true or false")
+		}
+		filter.Available = &available
+	}
+	if s := query.Get("includeDeleted"); len(s) > 0 {
+		if filter.IncludeDeleted, err = strconv.ParseBool(s); err != nil {
+			return nil, fmt.Errorf("includeDeleted must be true or false")
+		}
+	}
+	if s := query.Get("cursor"); len(s) > 0 {
+		if filter.Cursor, err = strconv.ParseBool(s); err != nil {
+			return nil, fmt.Errorf("cursor must be true or false")
+		}
+	}
+	return filter, nil
+}
+
+// maxSearchText is the maximum length of the text of a full-text search.
+const maxSearchText = 200
+
+func checkProductFilter(filter *ProductFilter) error {
+	if filter.Page > 0 && filter.CursorPaging() {
+		return fmt.Errorf("page cannot be used with cursor or nextPageToken")
+	}
+	if err := checkProjection(filter.Fields, filter.Include); err != nil {
+		return err
+	}
+	if filter.Filter != nil {
+		if len(filter.Q) > maxSearchText {
+			return fmt.Errorf("q cannot be longer than %d characters", maxSearchText)
+		}
+		if len(strings.TrimSpace(filter.Q)) == 0 {
+			for _, field := range strings.Split(filter.Sort, ",") {
+				if strings.TrimLeft(strings.TrimSpace(field), "+-") == "relevance" {
+					return fmt.Errorf("relevance can only be sorted with q")
+				}
+			}
+		}
+	}
+	if len(filter.Match) > 0 && !filter.Match.Valid() {
+		return fmt.Errorf("match must be '%s', '%s' or '%s'", MatchPrefix, MatchContains, MatchEqual)
+	}
+	for _, status := range filter.Status {
+		if !status.Valid() {
+			return fmt.Errorf("invalid product status %q", status)
+		}
+	}
+	for _, facet := range filter.Facets {
+		if !IsFacetField(facet) {
+			return fmt.Errorf("facets must be in %s, not %q", strings.Join(FacetFields, ", "), facet)
+		}
+	}
+	return nil
+}
+
+// queryList reads a parameter which may be repeated or separated by commas.
+func queryList(query url.Values, name string) []string {
+	var list []string
+	for _, s := range query[name] {
+		for _, v := range strings.Split(s, ",") {
+			if v = strings.TrimSpace(v); len(v) > 0 {
+				list = append(list, v)
+			}
+		}
+	}
+	return list
+}
+
+func queryInt(query url.Values, name string) (int64, error) {
+	s := query.Get(name)
+	if len(s) == 0 {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return n, nil
+}
+
+func queryRange(query url.Values, name string) (*search.NumberRange, error) {
+	min, max := query.Get(name+".min"), query.Get(name+".max")
+	if len(min) == 0 && len(max) == 0 {
+		return nil, nil
+	}
+	numberRange := &search.NumberRange{}
+	if len(min) > 0 {
+		v, err := strconv.ParseFloat(min, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s.min must be a number", name)
+		}
+		numberRange.Min = &v
+	}
+	if len(max) > 0 {
+		v, err := strconv.ParseFloat(max, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s.max must be a number", name)
+		}
+		numberRange.Max = &v
+	}
+	return numberRange, nil
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// StockResult is the stock of a product in each storage, and its total across storages.
+type StockResult struct {
+	List  []ProductDetails `json:"list"`
+	Total int              `json:"total"`
+}
+
+// Stocks lists the stocks of a product, one per storage.
+func (h *HttpProductHandler) Stocks(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if len(id) == 0 {
+		badRequest(w, "Id cannot be empty")
+		return
+	}
+	stocks, err := h.service.Stocks(r.Context(), id)
+	if err != nil {
+		RespondError(w, r, err, h.logError)
+		return
+	}
+	if stocks == nil {
+		stocks = make([]ProductDetails, 0)
+	}
+	JSON(w, http.StatusOK, StockResult{List: stocks, Total: TotalStock(stocks)})
+}
+
+// SetStock sets the stock of a product in a storage, from a body with its inStockAmount and its supplier.
+func (h *HttpProductHandler) SetStock(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, storage := vars["id"], vars["storage"]
+	if len(id) == 0 || len(storage) == 0 {
+		badRequest(w, "Id and storage cannot be empty")
+		return
+	}
+	var stock ProductDetails
+	er1 := json.NewDecoder(r.Body).Decode(&stock)
+	defer r.Body.Close()
+	if er1 != nil {
+		badRequest(w, er1.Error())
+		return
+	}
+	if len(stock.Storage) == 0 {
+		stock.Storage = storage
+	} else if stock.Storage != storage {
+		badRequest(w, "Storage not match")
+		return
+	}
+	version, er2 := IfMatch(r)
+	if er2 != nil {
+		badRequest(w, er2.Error())
+		return
+	}
+	newVersion, er3 := h.service.SetStock(r.Context(), id, version, stock)
+	if er3 != nil {
+		RespondError(w, r, er3, h.logError)
+		return
+	}
+	w.Header().Set("ETag", ETag(newVersion))
+	JSON(w, http.StatusOK, 1)
+}
+
+type StockMovementResult struct {
+	List  []StockMovement `json:"list"`
+	Total int64           `json:"total"`
+}
+
+// MoveStock applies a signed delta to the stock of a product in a storage, from a body with its storage, its delta,
+// its reason and its reference; it responds with the movement, which has the new stock of the storage as its balance.
+func (h *HttpProductHandler) MoveStock(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if len(id) == 0 {
+		badRequest(w, "Id cannot be empty")
+		return
+	}
+	var movement StockMovement
+	er1 := json.NewDecoder(r.Body).Decode(&movement)
+	defer r.Body.Close()
+	if er1 != nil {
+		badRequest(w, er1.Error())
+		return
+	}
+	if er2 := h.service.MoveStock(r.Context(), id, &movement); er2 != nil {
+		RespondError(w, r, er2, h.logError)
+		return
+	}
+	JSON(w, http.StatusCreated, movement)
+}
+
+// StockMovements lists the movements of the stock of a product, most recent first.
+func (h *HttpProductHandler) StockMovements(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if len(id) == 0 {
+		badRequest(w, "Id cannot be empty")
+		return
+	}
+	limit, offset, err := paging(r)
+	if err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+	list, total, err := h.service.StockMovements(r.Context(), id, limit, offset)
+	if err != nil {
+		RespondError(w, r, err, h.logError)
+		return
+	}
+	JSON(w, http.StatusOK, StockMovementResult{List: list, Total: total})
+}
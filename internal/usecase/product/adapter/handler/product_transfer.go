@@ -0,0 +1,291 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+const (
+	formatCsv    = "csv"
+	formatNdjson = "ndjson"
+	formatJson   = "json"
+
+	exportFlushSize = 100
+	importChunkSize = 1000
+	maxImportSize   = 64 << 20
+)
+
+type ImportError struct {
+	Row     int    `json:"row"`
+	Id      string `json:"id,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type ImportReport struct {
+	Total    int           `json:"total"`
+	Imported int           `json:"imported"`
+	Failed   int           `json:"failed"`
+	Errors   []ImportError `json:"errors,omitempty"`
+}
+
+// Export streams the products matching the filters of the query string, as csv or ndjson (format=csv|ndjson).
+func (h *HttpProductHandler) Export(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if len(format) == 0 {
+		format = formatCsv
+	}
+	if format != formatCsv && format != formatNdjson {
+		badRequest(w, "format must be 'csv' or 'ndjson'")
+		return
+	}
+	filter, err := productFilterFromQuery(r)
+	if err == nil {
+		err = checkProductFilter(filter)
+	}
+	if err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+
+	var write func(product *Product) error
+	var flush func() error
+	if format == formatCsv {
+		writer := csv.NewWriter(w)
+		write = func(product *Product) error {
+			return writer.Write(productToCsv(product))
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="products.csv"`)
+		if err = writer.Write(productCsvHeader); err != nil {
+			return
+		}
+	} else {
+		encoder := json.NewEncoder(w)
+		write = func(product *Product) error {
+			return encoder.Encode(product)
+		}
+		flush = func() error {
+			return nil
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="products.ndjson"`)
+	}
+
+	// the rows are sent every exportFlushSize products: the writer is flushed first, then the response
+	flusher, _ := w.(http.Flusher)
+	count := 0
+	err = h.service.Export(r.Context(), filter, func(product *Product) error {
+		if err := write(product); err != nil {
+			return err
+		}
+		if count++; count%exportFlushSize != 0 {
+			return nil
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil && count == 0 {
+		// nothing has been sent yet, so the failure can be answered with its status
+		w.Header().Del("Content-Disposition")
+		RespondError(w, r, err, h.logError)
+		return
+	}
+	if err == nil {
+		err = flush()
+	}
+	if err != nil && h.logError != nil {
+		// the status line has already been sent, so the failure can only be logged
+		h.logError(r.Context(), fmt.Sprintf("cannot export products: %s", err.Error()))
+	}
+}
+
+// Import creates or replaces the products of an uploaded csv, ndjson or json file, row by row.
+// The file is either the body or the "file" part of a multipart form; its format comes from the "format"
+// query parameter, the content type or the file extension. With report=csv, the error report is a csv file.
+func (h *HttpProductHandler) Import(w http.ResponseWriter, r *http.Request) {
+	body, format, err := importSource(w, r)
+	if err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+	defer body.Close()
+
+	report := ImportReport{}
+	var rows []int
+	var products []Product
+	add := func(row int, product Product) {
+		rows = append(rows, row)
+		products = append(products, product)
+	}
+	fail := func(row int, e ErrorMessage) {
+		report.Total++
+		report.Failed++
+		report.Errors = append(report.Errors, ImportError{Row: row, Field: e.Field, Code: e.Code, Message: e.Message})
+	}
+	switch format {
+	case formatCsv:
+		err = readProductCsv(body, add, fail)
+	case formatNdjson:
+		err = readProductNdjson(body, add, fail)
+	default:
+		err = readProductJson(body, add, fail)
+	}
+	if err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+
+	for start := 0; start < len(products); start += importChunkSize {
+		end := start + importChunkSize
+		if end > len(products) {
+			end = len(products)
+		}
+		if err = r.Context().Err(); err != nil {
+			// the client is gone, so the rows left are not imported
+			h.failImport(r, &report, rows[start:], products[start:], err)
+			break
+		}
+		results, err := h.service.UpsertBatch(r.Context(), products[start:end], false)
+		if err != nil {
+			// the chunks before are committed, so the report tells which rows are imported, and the next chunks are tried
+			h.failImport(r, &report, rows[start:end], products[start:end], err)
+			continue
+		}
+		for i, result := range results {
+			row, id := rows[start+i], products[start+i].GeneralInfo.Id
+			report.Total++
+			if result.Status == 1 {
+				report.Imported++
+				continue
+			}
+			report.Failed++
+			if len(result.Errors) == 0 {
+				report.Errors = append(report.Errors, ImportError{Row: row, Id: id, Message: result.Message})
+			}
+			for _, e := range result.Errors {
+				report.Errors = append(report.Errors, ImportError{Row: row, Id: id, Field: e.Field, Code: e.Code, Message: e.Message})
+			}
+		}
+	}
+
+	if r.URL.Query().Get("report") == formatCsv {
+		writeImportReportCsv(w, report)
+		return
+	}
+	JSON(w, http.StatusOK, report)
+}
+
+// failImport reports the rows of products which could not be upserted because of an error which is not about the rows.
+func (h *HttpProductHandler) failImport(r *http.Request, report *ImportReport, rows []int, products []Product, err error) {
+	h.logError(r.Context(), fmt.Sprintf("cannot import %d products from row %d: %s", len(products), rows[0], err.Error()))
+	for i := range products {
+		report.Total++
+		report.Failed++
+		report.Errors = append(report.Errors, ImportError{Row: rows[i], Id: products[i].GeneralInfo.Id, Code: codeInternal, Message: http.StatusText(http.StatusInternalServerError)})
+	}
+}
+
+func importSource(w http.ResponseWriter, r *http.Request) (io.ReadCloser, string, error) {
+	format := r.URL.Query().Get("format")
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	body := r.Body
+	if contentType == "multipart/form-data" {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, "", fmt.Errorf("cannot read the 'file' part: %s", err.Error())
+		}
+		body = file
+		contentType = header.Header.Get("Content-Type")
+		if len(format) == 0 {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+		}
+	}
+	if len(format) == 0 {
+		switch contentType {
+		case "text/csv":
+			format = formatCsv
+		case "application/x-ndjson", "application/ndjson":
+			format = formatNdjson
+		default:
+			format = formatJson
+		}
+	}
+	if format != formatCsv && format != formatNdjson && format != formatJson {
+		body.Close()
+		return nil, "", fmt.Errorf("format must be 'csv', 'ndjson' or 'json', not '%s'", format)
+	}
+	return body, format, nil
+}
+
+// readProductNdjson reads one product per line; blank lines are skipped.
+func readProductNdjson(r io.Reader, add func(line int, product Product), fail func(line int, e ErrorMessage)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var product Product
+		if err := json.Unmarshal(data, &product); err != nil {
+			fail(line, ErrorMessage{Code: "format", Message: err.Error()})
+			continue
+		}
+		add(line, product)
+	}
+	return scanner.Err()
+}
+
+// readProductJson reads a json array of products, such as data/data.json; rows are numbered from 1.
+func readProductJson(r io.Reader, add func(line int, product Product), fail func(line int, e ErrorMessage)) error {
+	var items []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return fmt.Errorf("body must be a json array of products: %s", err.Error())
+	}
+	for i, item := range items {
+		var product Product
+		if err := json.Unmarshal(item, &product); err != nil {
+			fail(i+1, ErrorMessage{Code: "format", Message: err.Error()})
+			continue
+		}
+		add(i+1, product)
+	}
+	return nil
+}
+
+func writeImportReportCsv(w http.ResponseWriter, report ImportReport) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="import-errors.csv"`)
+	w.WriteHeader(http.StatusOK)
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"row", "id", "field", "code", "message"})
+	for _, e := range report.Errors {
+		writer.Write([]string{strconv.Itoa(e.Row), e.Id, e.Field, e.Code, e.Message})
+	}
+	writer.Flush()
+}
@@ -0,0 +1,35 @@
+package publisher
+
+import (
+	"context"
+	"sync"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// MemoryPublisher keeps published events in memory, for tests such as the ones of the outbox relay; the events are
+// lost when the process stops.
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []ProductEvent
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, event ProductEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events returns a copy of the events published so far.
+func (p *MemoryPublisher) Events() []ProductEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	events := make([]ProductEvent, len(p.events))
+	copy(events, p.events)
+	return events
+}
@@ -0,0 +1,51 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+type WebhookConfig struct {
+	Url     string        `mapstructure:"url"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// WebhookPublisher posts every event as JSON to a webhook url.
+// The X-Event-Id header lets the receiver drop the duplicates of at-least-once delivery.
+type WebhookPublisher struct {
+	Client *http.Client
+	Url    string
+}
+
+func NewWebhookPublisher(conf WebhookConfig) *WebhookPublisher {
+	return &WebhookPublisher{Client: &http.Client{Timeout: conf.Timeout}, Url: conf.Url}
+}
+
+func (p *WebhookPublisher) Publish(ctx context.Context, event ProductEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Id", event.Id)
+	req.Header.Set("X-Event-Type", string(event.Type))
+	res, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s answered %s for event %s", p.Url, res.Status, event.Id)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	q "github.com/core-go/sql"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+func NewIdempotencyAdapter(db *sql.DB) *IdempotencyAdapter {
+	return &IdempotencyAdapter{DB: db}
+}
+
+// IdempotencyAdapter stores the responses of idempotent requests in idempotency_keys.
+// It does not use the transaction of the context: a record must outlive the request which stores it.
+type IdempotencyAdapter struct {
+	DB *sql.DB
+}
+
+func (r *IdempotencyAdapter) Begin(ctx context.Context, record *IdempotencyRecord) (*IdempotencyRecord, error) {
+	queryExpired := fmt.Sprintf("delete from idempotency_keys where actor = %s and idempotencyKey = %s and expiresAt < %s", q.BuildParam(1), q.BuildParam(2), q.BuildParam(3))
+	if _, err := r.DB.ExecContext(ctx, queryExpired, record.Actor, record.Key, time.Now()); err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf("insert into idempotency_keys (actor, idempotencyKey, requestHash, owner, statusCode, createdAt, expiresAt, lockedUntil) values (%s, %s, %s, %s, 0, %s, %s, %s)",
+		q.BuildParam(1), q.BuildParam(2), q.BuildParam(3), q.BuildParam(4), q.BuildParam(5), q.BuildParam(6), q.BuildParam(7))
+	_, err := r.DB.ExecContext(ctx, query, record.Actor, record.Key, record.RequestHash, record.Owner, record.CreatedAt, record.ExpiresAt, record.LockedUntil)
+	if err == nil {
+		return nil, nil
+	}
+	var conflict *ConflictError
+	if !errors.As(translateError(err, record.Key), &conflict) || conflict.Code != CodeDuplicateKey {
+		return nil, err
+	}
+	// another request has the key: it is either in progress, renewing its lock, or done, or it was abandoned
+	// in progress, because its process stopped, and its lock is over
+	queryTakeOver := fmt.Sprintf("update idempotency_keys set owner = %s, createdAt = %s, expiresAt = %s, lockedUntil = %s where actor = %s and idempotencyKey = %s and requestHash = %s and statusCode = 0 and lockedUntil < %s",
+		q.BuildParam(1), q.BuildParam(2), q.BuildParam(3), q.BuildParam(4), q.BuildParam(5), q.BuildParam(6), q.BuildParam(7), q.BuildParam(8))
+	res, err := r.DB.ExecContext(ctx, queryTakeOver, record.Owner, record.CreatedAt, record.ExpiresAt, record.LockedUntil, record.Actor, record.Key, record.RequestHash, record.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil, nil
+	}
+	return r.load(ctx, record.Actor, record.Key)
+}
+
+func (r *IdempotencyAdapter) load(ctx context.Context, actor string, key string) (*IdempotencyRecord, error) {
+	query := fmt.Sprintf("select actor, idempotencyKey, requestHash, owner, statusCode, header, body, createdAt, expiresAt, lockedUntil from idempotency_keys where actor = %s and idempotencyKey = %s", q.BuildParam(1), q.BuildParam(2))
+	var record IdempotencyRecord
+	var header []byte
+	err := r.DB.QueryRowContext(ctx, query, actor, key).Scan(&record.Actor, &record.Key, &record.RequestHash, &record.Owner, &record.StatusCode, &header, &record.Body, &record.CreatedAt, &record.ExpiresAt, &record.LockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	if len(header) > 0 {
+		if err = json.Unmarshal(header, &record.Header); err != nil {
+			return nil, err
+		}
+	}
+	return &record, nil
+}
+
+func (r *IdempotencyAdapter) Renew(ctx context.Context, record *IdempotencyRecord) error {
+	query := fmt.Sprintf("update idempotency_keys set lockedUntil = %s where actor = %s and idempotencyKey = %s and owner = %s and statusCode = 0",
+		q.BuildParam(1), q.BuildParam(2), q.BuildParam(3), q.BuildParam(4))
+	_, err := r.DB.ExecContext(ctx, query, record.LockedUntil, record.Actor, record.Key, record.Owner)
+	return err
+}
+
+func (r *IdempotencyAdapter) Complete(ctx context.Context, record *IdempotencyRecord) error {
+	header, err := json.Marshal(record.Header)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("update idempotency_keys set statusCode = %s, header = %s, body = %s where actor = %s and idempotencyKey = %s and owner = %s and statusCode = 0",
+		q.BuildParam(1), q.BuildParam(2), q.BuildParam(3), q.BuildParam(4), q.BuildParam(5), q.BuildParam(6))
+	res, err := r.DB.ExecContext(ctx, query, record.StatusCode, header, record.Body, record.Actor, record.Key, record.Owner)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	return fmt.Errorf("idempotency key '%s' is not held by this request any more", record.Key)
+}
+
+func (r *IdempotencyAdapter) Remove(ctx context.Context, record *IdempotencyRecord) error {
+	query := fmt.Sprintf("delete from idempotency_keys where actor = %s and idempotencyKey = %s and owner = %s", q.BuildParam(1), q.BuildParam(2), q.BuildParam(3))
+	_, err := r.DB.ExecContext(ctx, query, record.Actor, record.Key, record.Owner)
+	return err
+}
+
+// Purge removes the records which expired before a time.
+func (r *IdempotencyAdapter) Purge(ctx context.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf("delete from idempotency_keys where expiresAt < %s", q.BuildParam(1))
+	res, err := r.DB.ExecContext(ctx, query, before)
+	if err != nil {
+		return -1, err
+	}
+	return res.RowsAffected()
+}
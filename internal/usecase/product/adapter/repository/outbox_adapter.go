@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	q "github.com/core-go/sql"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+func NewOutboxAdapter(db *sql.DB) *OutboxAdapter {
+	return &OutboxAdapter{DB: db}
+}
+
+// OutboxAdapter stores product events in the product_outbox table until they are published.
+type OutboxAdapter struct {
+	DB *sql.DB
+}
+
+// Insert writes the event in the transaction of the context, so it is only stored if the change is committed.
+func (r *OutboxAdapter) Insert(ctx context.Context, event *ProductEvent) error {
+	tx := GetTx(ctx)
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("insert into product_outbox (id, eventType, productId, occurredAt, payload, attempts, nextAttemptAt) values (%s, %s, %s, %s, %s, 0, %s)",
+		q.BuildParam(1), q.BuildParam(2), q.BuildParam(3), q.BuildParam(4), q.BuildParam(5), q.BuildParam(6))
+	_, err = tx.ExecContext(ctx, query, event.Id, string(event.Type), event.ProductId, event.OccurredAt, string(payload), event.OccurredAt)
+	return err
+}
+
+// Pending returns the unpublished events which are due, in the order they occurred. An event which comes after
+// a failed event of the same product is not due until the failed event is retried, so that the events of a product
+// are published in order.
+func (r *OutboxAdapter) Pending(ctx context.Context, limit int) ([]ProductEvent, error) {
+	query := fmt.Sprintf(`select o.payload from product_outbox o where o.publishedAt is null and o.nextAttemptAt <= %s
+		and not exists (select 1 from product_outbox f where f.productId = o.productId and f.publishedAt is null and f.nextAttemptAt > %s
+			and (f.occurredAt < o.occurredAt or (f.occurredAt = o.occurredAt and f.id < o.id)))
+		order by o.occurredAt, o.id limit %d`, q.BuildParam(1), q.BuildParam(2), limit)
+	now := time.Now()
+	rows, err := r.DB.QueryContext(ctx, query, now, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []ProductEvent
+	for rows.Next() {
+		var payload []byte
+		if err = rows.Scan(&payload); err != nil {
+			return nil, err
+		}
+		var event ProductEvent
+		if err = json.Unmarshal(payload, &event); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (r *OutboxAdapter) MarkPublished(ctx context.Context, id string) error {
+	query := fmt.Sprintf("update product_outbox set publishedAt = %s, attempts = attempts + 1, lastError = null where id = %s", q.BuildParam(1), q.BuildParam(2))
+	_, err := r.DB.ExecContext(ctx, query, time.Now(), id)
+	return err
+}
+
+func (r *OutboxAdapter) MarkFailed(ctx context.Context, id string, reason string, retryAt time.Time) error {
+	query := fmt.Sprintf("update product_outbox set attempts = attempts + 1, lastError = %s, nextAttemptAt = %s where id = %s", q.BuildParam(1), q.BuildParam(2), q.BuildParam(3))
+	_, err := r.DB.ExecContext(ctx, query, reason, retryAt, id)
+	return err
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	q "github.com/core-go/sql"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+func NewPriceAdapter(db *sql.DB) *PriceAdapter {
+	return &PriceAdapter{DB: db}
+}
+
+// PriceAdapter stores the scheduled prices in product_prices, and the price history in price_history.
+type PriceAdapter struct {
+	DB *sql.DB
+}
+
+func (r *PriceAdapter) Insert(ctx context.Context, price *ScheduledPrice) error {
+	query := fmt.Sprintf("insert into product_prices (id, productId, price, effectiveFrom, effectiveTo, priority, actor, createdAt) values (%s, %s, %s, %s, %s, %s, %s, %s)",
+		q.BuildParam(1), q.BuildParam(2), q.BuildParam(3), q.BuildParam(4), q.BuildParam(5), q.BuildParam(6), q.BuildParam(7), q.BuildParam(8))
+	_, err := GetTx(ctx).ExecContext(ctx, query, price.Id, price.ProductId, price.Price, price.EffectiveFrom, price.EffectiveTo, price.Priority, price.Actor, price.CreatedAt)
+	return err
+}
+
+// List returns the scheduled prices of a product, the latest to start first, and their total.
+func (r *PriceAdapter) List(ctx context.Context, productId string, limit int64, offset int64) ([]ScheduledPrice, int64, error) {
+	var total int64
+	queryCount := fmt.Sprintf("select count(*) from product_prices where productId = %s", q.BuildParam(1))
+	if err := r.DB.QueryRowContext(ctx, queryCount, productId).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	query := fmt.Sprintf("select id, productId, price, effectiveFrom, effectiveTo, priority, actor, createdAt from product_prices where productId = %s order by effectiveFrom desc, createdAt desc, id desc limit %d offset %d", q.BuildParam(1), limit, offset)
+	rows, err := r.DB.QueryContext(ctx, query, productId)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	prices := make([]ScheduledPrice, 0)
+	for rows.Next() {
+		var price ScheduledPrice
+		if err = rows.Scan(&price.Id, &price.ProductId, &price.Price, &price.EffectiveFrom, &price.EffectiveTo, &price.Priority, &price.Actor, &price.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		prices = append(prices, price)
+	}
+	return prices, total, rows.Err()
+}
+
+// Pending reads the products which have a scheduled price in effect which is not stored in price yet, or which still
+// store a scheduled price which is no longer in effect, ordered by id. A product whose price could not be stored is
+// left out until the next attempt of price_failures.
+func (r *PriceAdapter) Pending(ctx context.Context, now time.Time, limit int) ([]PendingPrice, error) {
+	query := fmt.Sprintf("select p.id, p.version, p.price, p.basePrice, coalesce(e.id, ''), e.price, coalesce(f.attempts, 0) from products p%s"+
+		" left join price_failures f on f.productId = p.id where p.deletedAt is null and (f.productId is null or f.nextAttemptAt <= %s)"+
+		" and ((e.id is null and p.basePrice is not null) or (e.id is not null and (p.basePrice is null or p.price <> e.price))) order by p.id limit %d",
+		scheduledPriceJoin(q.BuildParam(1), q.BuildParam(2)), q.BuildParam(3), limit)
+	rows, err := r.DB.QueryContext(ctx, query, now, now, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var pending []PendingPrice
+	for rows.Next() {
+		var price PendingPrice
+		if err = rows.Scan(&price.ProductId, &price.Version, &price.Price, &price.BasePrice, &price.ScheduleId, &price.ScheduledPrice, &price.Attempts); err != nil {
+			return nil, err
+		}
+		pending = append(pending, price)
+	}
+	return pending, rows.Err()
+}
+
+// MarkFailed records that the price of a product could not be stored, so that it is not pending again before retryAt.
+func (r *PriceAdapter) MarkFailed(ctx context.Context, productId string, reason string, retryAt time.Time) error {
+	query := fmt.Sprintf("insert into price_failures (productId, attempts, lastError, nextAttemptAt) values (%s, 1, %s, %s)"+
+		" on duplicate key update attempts = attempts + 1, lastError = values(lastError), nextAttemptAt = values(nextAttemptAt)",
+		q.BuildParam(1), q.BuildParam(2), q.BuildParam(3))
+	_, err := r.DB.ExecContext(ctx, query, productId, reason, retryAt)
+	return err
+}
+
+// ClearFailure removes the failure of a product, in the transaction of the context which stores its price.
+func (r *PriceAdapter) ClearFailure(ctx context.Context, productId string) error {
+	query := fmt.Sprintf("delete from price_failures where productId = %s", q.BuildParam(1))
+	_, err := GetTx(ctx).ExecContext(ctx, query, productId)
+	return err
+}
+
+// InsertChange writes an entry of the price history in the transaction of the context, together with the change of the price.
+func (r *PriceAdapter) InsertChange(ctx context.Context, change *PriceChange) error {
+	query := fmt.Sprintf("insert into price_history (productId, price, previousPrice, scheduleId, actor, changedAt) values (%s, %s, %s, %s, %s, %s)",
+		q.BuildParam(1), q.BuildParam(2), q.BuildParam(3), q.BuildParam(4), q.BuildParam(5), q.BuildParam(6))
+	var scheduleId *string
+	if len(change.ScheduleId) > 0 {
+		scheduleId = &change.ScheduleId
+	}
+	res, err := GetTx(ctx).ExecContext(ctx, query, change.ProductId, change.Price, change.PreviousPrice, scheduleId, change.Actor, change.ChangedAt)
+	if err != nil {
+		return err
+	}
+	change.Id, err = res.LastInsertId()
+	return err
+}
+
+func (r *PriceAdapter) LatestChange(ctx context.Context, productId string) (*PriceChange, error) {
+	query := fmt.Sprintf("select %s from price_history where productId = %s order by changedAt desc, id desc limit 1", priceChangeColumns, q.BuildParam(1))
+	var change PriceChange
+	err := r.executor(ctx).QueryRowContext(ctx, query, productId).Scan(priceChangeDest(&change)...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// History returns the price history of a product, most recent first, and its total.
+func (r *PriceAdapter) History(ctx context.Context, productId string, limit int64, offset int64) ([]PriceChange, int64, error) {
+	var total int64
+	queryCount := fmt.Sprintf("select count(*) from price_history where productId = %s", q.BuildParam(1))
+	if err := r.DB.QueryRowContext(ctx, queryCount, productId).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	query := fmt.Sprintf("select %s from price_history where productId = %s order by changedAt desc, id desc limit %d offset %d", priceChangeColumns, q.BuildParam(1), limit, offset)
+	rows, err := r.DB.QueryContext(ctx, query, productId)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	changes := make([]PriceChange, 0)
+	for rows.Next() {
+		var change PriceChange
+		if err = rows.Scan(priceChangeDest(&change)...); err != nil {
+			return nil, 0, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, total, rows.Err()
+}
+
+func (r *PriceAdapter) executor(ctx context.Context) executor {
+	if tx := GetTx(ctx); tx != nil {
+		return tx
+	}
+	return r.DB
+}
+
+const priceChangeColumns = "id, productId, price, previousPrice, coalesce(scheduleId, ''), actor, changedAt"
+
+func priceChangeDest(change *PriceChange) []interface{} {
+	return []interface{}{&change.Id, &change.ProductId, &change.Price, &change.PreviousPrice, &change.ScheduleId, &change.Actor, &change.ChangedAt}
+}
@@ -8,68 +8,101 @@ import (
 	q "github.com/core-go/sql"
 	. "go-service/internal/usecase/product/domain"
 	"reflect"
+	"time"
 )
 
-func NewProductAdapter(db *sql.DB) *ProductAdapter {
-	return &ProductAdapter{DB: db}
+const productResource = "product"
+
+var productKeys = []string{"id"}
+
+// NewProductAdapter creates the product repository; with softDelete, Delete only marks products as deleted.
+// The driver is the database/sql driver name.
+func NewProductAdapter(db *sql.DB, driver string, softDelete bool) *ProductAdapter {
+	return &ProductAdapter{DB: db, Driver: driver, SoftDelete: softDelete}
 }
 
 type ProductAdapter struct {
-	DB *sql.DB
+	DB         *sql.DB
+	Driver     string
+	SoftDelete bool
 }
 
 func (r *ProductAdapter) Load(ctx context.Context, id string) (*Product, error) {
-	var productGeneral []ProductGeneral
-	var productDetails []ProductDetails
-
-	queryGeneral := fmt.Sprintf("select id, productName, description, price, status from products where id = %s limit 1", q.BuildParam(1))
-	err := q.Query(ctx, r.DB, nil, &productGeneral, queryGeneral, id)
+	exec := r.executor(ctx)
+	var product Product
+	g := &product.GeneralInfo
+	queryGeneral := fmt.Sprintf("select %s from products p%s where p.id = %s and p.deletedAt is null limit 1", productGeneralColumns, scheduledPriceJoin(q.BuildParam(1), q.BuildParam(2)), q.BuildParam(3))
+	now := time.Now()
+	err := exec.QueryRowContext(ctx, queryGeneral, now, now, id).Scan(productGeneralDest(g)...)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: productResource, Id: id}
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	queryDetails := fmt.Sprintf("select productID, supplier, storage, inStockAmount from product_details where productID = %s limit 1", q.BuildParam(1))
-	err = q.Query(ctx, r.DB, nil, &productDetails, queryDetails, id)
+	stocks, err := loadStocks(ctx, exec, []string{id})
 	if err != nil {
 		return nil, err
 	}
+	product.Stocks = stocks[id]
+	product.DetailInfo = SummarizeStocks(id, product.Stocks)
+	return &product, nil
+}
 
-	var product Product
-	product.GeneralInfo = productGeneral[0]
-	product.DetailInfo = productDetails[0]
+// LoadForUpdate locks the row of a product, deleted or not, in the transaction of ctx, then loads the product.
+// The lock is taken before the product is read, so that the product cannot change until the transaction ends.
+// A deleted product is a conflict, with the code CodeDeleted.
+func (r *ProductAdapter) LoadForUpdate(ctx context.Context, id string) (*Product, error) {
+	var deletedAt *time.Time
+	query := fmt.Sprintf("select deletedAt from products where id = %s for update", q.BuildParam(1))
+	err := GetTx(ctx).QueryRowContext(ctx, query, id).Scan(&deletedAt)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: productResource, Id: id}
+	}
+	if err != nil {
+		return nil, translateError(err, id)
+	}
+	if deletedAt != nil {
+		return nil, deletedError(id)
+	}
+	return r.Load(ctx, id)
+}
 
-	if &product != nil {
-		return &product, nil
+// LoadProjection loads the columns of a projection of a product, with its details in the same query.
+func (r *ProductAdapter) LoadProjection(ctx context.Context, id string, projection *ProductProjection) (*Product, error) {
+	columns := selectProductColumns(projection)
+	query, args := buildProductQuery(&ProductFilter{Id: id}, false, columns)
+	rows, err := r.executor(ctx).QueryContext(ctx, query+" limit 1", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, &NotFoundError{Resource: productResource, Id: id}
 	}
-	return nil, nil
+	return scanProduct(rows, columns, false)
 }
 
 func (r *ProductAdapter) Create(ctx context.Context, product *Product) (int64, error) {
 	tx := GetTx(ctx)
 	var rowsAffected int64
 
-	if product.DetailInfo.InStockAmount > 0 {
-		product.GeneralInfo.Status = "available"
-	} else {
-		product.GeneralInfo.Status = "not available"
-	}
-
+	product.GeneralInfo.Version = 1
 	queryGeneral, argsGeneral := q.BuildToInsert("products", product.GeneralInfo, q.BuildParam)
 	_, errGeneral := tx.ExecContext(ctx, queryGeneral, argsGeneral...)
 	if errGeneral != nil {
-		return -1, errGeneral
+		return -1, translateError(errGeneral, product.GeneralInfo.Id)
 	} else {
 		rowsAffected++
 	}
 
-	if checkDetailReq := checkReqProductDetails(product.DetailInfo); checkDetailReq == nil {
-		queryDetails, argsDetails := q.BuildToInsert("product_details", product.DetailInfo, q.BuildParam)
-		_, errDetails := tx.ExecContext(ctx, queryDetails, argsDetails...)
-		if errDetails != nil {
-			return -1, errDetails
-		} else {
-			rowsAffected++
-		}
+	// the stocks are part of the product, so they are not counted in the affected rows
+	if err := insertStocks(ctx, tx, productStocks(product)); err != nil {
+		return -1, err
 	}
 
 	return rowsAffected, nil
@@ -79,84 +112,170 @@ func (r *ProductAdapter) Update(ctx context.Context, product *Product) (int64, e
 	tx := GetTx(ctx)
 	var rowsAffected int64
 
-	if product.DetailInfo.InStockAmount > 0 {
-		product.GeneralInfo.Status = "available"
-	} else {
-		product.GeneralInfo.Status = "not available"
+	id := product.GeneralInfo.Id
+	queryGeneral, argsGeneral := buildToUpdateWithVersion("products", toColumns(product.GeneralInfo), id, product.GeneralInfo.Version)
+	res, err := tx.ExecContext(ctx, queryGeneral, argsGeneral...)
+	if err != nil {
+		return -1, translateError(err, id)
 	}
-
-	queryGeneral, argsGeneral := q.BuildToUpdate("products", product.GeneralInfo, q.BuildParam)
-	_, err := tx.ExecContext(ctx, queryGeneral, argsGeneral...)
+	version, err := checkVersion(ctx, tx, res, id, product.GeneralInfo.Version)
 	if err != nil {
 		return -1, err
-	} else {
-		rowsAffected++
 	}
+	product.GeneralInfo.Version = version
+	rowsAffected++
 
-	if checkDetailReq := checkReqProductDetails(product.DetailInfo); checkDetailReq == nil {
-		queryDetails, argsDetails := q.BuildToUpdate("product_details", product.DetailInfo, q.BuildParam)
-		_, err1 := tx.ExecContext(ctx, queryDetails, argsDetails...)
-		if err1 != nil {
-			return -1, err1
-		} else {
-			rowsAffected++
-		}
+	// the stocks replace the ones of the product; they are not counted in the affected rows
+	if err = replaceStocks(ctx, tx, []string{id}, productStocks(product)); err != nil {
+		return -1, err
 	}
 
 	return rowsAffected, nil
 }
 
-func (r *ProductAdapter) Patch(ctx context.Context, product map[string]interface{}) (int64, error) {
-	tx := GetTx(ctx)
-
-	productType := reflect.TypeOf(ProductGeneral{})
-	jsonColumnMap := q.MakeJsonColumnMap(productType)
-	colMap := q.JSONToColumns(product, jsonColumnMap)
-	keys, _ := q.FindPrimaryKeys(productType)
+// Upsert inserts a product, or replaces the one with the same id, which is no longer deleted if it was.
+// Whether it inserts or replaces, it affects one product.
+func (r *ProductAdapter) Upsert(ctx context.Context, product *Product) (int64, error) {
+	if _, err := r.insertBatch(ctx, []Product{*product}, true); err != nil {
+		return -1, err
+	}
+	return 1, nil
+}
 
-	query, args := q.BuildToPatch("products", colMap, keys, q.BuildParam)
-	res, err := tx.ExecContext(ctx, query, args...)
+// UpdateStatus sets the status of a product, if it has the given version, and increments its version.
+func (r *ProductAdapter) UpdateStatus(ctx context.Context, id string, version int64, status ProductStatus) (int64, error) {
+	tx := GetTx(ctx)
+	query := fmt.Sprintf("update products set status = %s, version = version + 1 where id = %s and version = %s and deletedAt is null", q.BuildParam(1), q.BuildParam(2), q.BuildParam(3))
+	res, err := tx.ExecContext(ctx, query, string(status), id, version)
 	if err != nil {
+		return -1, translateError(err, id)
+	}
+	if _, err = checkVersion(ctx, tx, res, id, version); err != nil {
 		return -1, err
 	}
+	return 1, nil
+}
 
+// UpdatePrice stores the price in effect of a product and its base price, if it has the given version, and increments its version.
+func (r *ProductAdapter) UpdatePrice(ctx context.Context, id string, version int64, price Amount, basePrice *Amount) (int64, error) {
+	query := fmt.Sprintf("update products set price = %s, basePrice = %s, version = version + 1 where id = %s and version = %s and deletedAt is null", q.BuildParam(1), q.BuildParam(2), q.BuildParam(3), q.BuildParam(4))
+	res, err := GetTx(ctx).ExecContext(ctx, query, price, basePrice, id, version)
+	if err != nil {
+		return -1, translateError(err, id)
+	}
 	return res.RowsAffected()
 }
 
-func (r *ProductAdapter) Delete(ctx context.Context, id string) (int64, error) {
+func (r *ProductAdapter) Delete(ctx context.Context, id string, version int64) (int64, error) {
 	tx := GetTx(ctx)
 	var rowsAffected int64
 
+	current, err := currentVersion(ctx, tx, id)
+	if err != nil {
+		return -1, err
+	}
+	if version > 0 && current != version {
+		return -1, &VersionMismatchError{Resource: productResource, Id: id}
+	}
+	if r.SoftDelete {
+		query := fmt.Sprintf("update products set deletedAt = %s, deletedBy = %s, version = version + 1 where id = %s", q.BuildParam(1), q.BuildParam(2), q.BuildParam(3))
+		if _, err = tx.ExecContext(ctx, query, time.Now(), ActorFromContext(ctx), id); err != nil {
+			return -1, translateError(err, id)
+		}
+		return 1, nil
+	}
+
 	queryDetails := fmt.Sprintf("delete from product_details where productId = %s", q.BuildParam(1))
-	_, er1 := tx.ExecContext(ctx, queryDetails, id)
+	res, er1 := tx.ExecContext(ctx, queryDetails, id)
 	if er1 != nil {
-		return -1, er1
-	} else {
+		return -1, translateError(er1, id)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
 		rowsAffected++
 	}
 
+	if err = deleteProductRows(ctx, tx, "= "+q.BuildParam(1), id); err != nil {
+		return -1, err
+	}
+
 	queryGeneral := fmt.Sprintf("delete from products where id = %s", q.BuildParam(1))
 	_, er2 := tx.ExecContext(ctx, queryGeneral, id)
 	if er2 != nil {
-		return -1, er2
-	} else {
-		rowsAffected++
+		return -1, translateError(er2, id)
 	}
+	rowsAffected++
 
 	return rowsAffected, nil
 }
 
-func GetTx(ctx context.Context) *sql.Tx {
-	txi := ctx.Value("tx")
-	if txi != nil {
-		txx, ok := txi.(*sql.Tx)
-		if ok {
-			return txx
+// Restore brings back a soft deleted product.
+func (r *ProductAdapter) Restore(ctx context.Context, id string, version int64) (int64, error) {
+	tx := GetTx(ctx)
+	var deletedAt *time.Time
+	var current int64
+	query := fmt.Sprintf("select version, deletedAt from products where id = %s for update", q.BuildParam(1))
+	err := tx.QueryRowContext(ctx, query, id).Scan(&current, &deletedAt)
+	if err == sql.ErrNoRows {
+		return -1, &NotFoundError{Resource: productResource, Id: id}
+	}
+	if err != nil {
+		return -1, err
+	}
+	if deletedAt == nil {
+		return -1, &ConflictError{Resource: productResource, Id: id, Code: CodeNotDeleted, Message: fmt.Sprintf("%s '%s' is not deleted", productResource, id)}
+	}
+	if version > 0 && current != version {
+		return -1, &VersionMismatchError{Resource: productResource, Id: id}
+	}
+	queryRestore := fmt.Sprintf("update products set deletedAt = null, deletedBy = null, version = version + 1 where id = %s", q.BuildParam(1))
+	if _, err = tx.ExecContext(ctx, queryRestore, id); err != nil {
+		return -1, translateError(err, id)
+	}
+	return 1, nil
+}
+
+// Purge permanently removes the products which have been soft deleted before the given time.
+func (r *ProductAdapter) Purge(ctx context.Context, before time.Time) (int64, error) {
+	tx := GetTx(ctx)
+	queryDetails := fmt.Sprintf("delete from product_details where productID in (select id from products where deletedAt < %s)", q.BuildParam(1))
+	if _, err := tx.ExecContext(ctx, queryDetails, before); err != nil {
+		return -1, err
+	}
+	if err := deleteProductRows(ctx, tx, fmt.Sprintf("in (select id from products where deletedAt < %s)", q.BuildParam(1)), before); err != nil {
+		return -1, err
+	}
+	queryGeneral := fmt.Sprintf("delete from products where deletedAt < %s", q.BuildParam(1))
+	res, err := tx.ExecContext(ctx, queryGeneral, before)
+	if err != nil {
+		return -1, err
+	}
+	return res.RowsAffected()
+}
+
+// productRows are the tables, apart from product_details, of the rows which belong to a product and are removed with it.
+// The audit entries and the events are kept, as the history of the product.
+var productRows = []string{"product_prices", "product_reservations", "stock_movements", "price_history", "price_failures"}
+
+// deleteProductRows removes the rows of productRows which belong to the products whose id matches a condition,
+// such as "in (?, ?)".
+func deleteProductRows(ctx context.Context, exec executor, condition string, args ...interface{}) error {
+	for _, table := range productRows {
+		query := fmt.Sprintf("delete from %s where productId %s", table, condition)
+		if _, err := exec.ExecContext(ctx, query, args...); err != nil {
+			return translateError(err, "")
 		}
 	}
 	return nil
 }
 
+// executor returns the transaction of the context, so reads see the writes of the current transaction.
+func (r *ProductAdapter) executor(ctx context.Context) executor {
+	if tx := GetTx(ctx); tx != nil {
+		return tx
+	}
+	return r.DB
+}
+
 func checkReqProductDetails(productDetails ProductDetails) error {
 	count := 0
 	v := reflect.ValueOf(productDetails)
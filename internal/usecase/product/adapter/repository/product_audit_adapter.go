@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	q "github.com/core-go/sql"
+	. "go-service/internal/usecase/product/domain"
+)
+
+func NewProductAuditAdapter(db *sql.DB) *ProductAuditAdapter {
+	return &ProductAuditAdapter{DB: db}
+}
+
+type ProductAuditAdapter struct {
+	DB *sql.DB
+}
+
+// Insert writes the audit entry in the transaction of the context, together with the mutation it records.
+func (r *ProductAuditAdapter) Insert(ctx context.Context, audit *ProductAudit) error {
+	tx := GetTx(ctx)
+	diff, err := json.Marshal(audit.Diff)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("insert into product_audits (productId, operation, actor, changedAt, beforeState, afterState, diff) values (%s, %s, %s, %s, %s, %s, %s)",
+		q.BuildParam(1), q.BuildParam(2), q.BuildParam(3), q.BuildParam(4), q.BuildParam(5), q.BuildParam(6), q.BuildParam(7))
+	res, err := tx.ExecContext(ctx, query, audit.ProductId, string(audit.Operation), audit.Actor, audit.ChangedAt, nullJSON(audit.Before), nullJSON(audit.After), string(diff))
+	if err != nil {
+		return err
+	}
+	audit.Id, err = res.LastInsertId()
+	return err
+}
+
+// History returns the audit entries of a product, most recent first, and their total.
+func (r *ProductAuditAdapter) History(ctx context.Context, productId string, limit int64, offset int64) ([]ProductAudit, int64, error) {
+	var total int64
+	queryCount := fmt.Sprintf("select count(*) from product_audits where productId = %s", q.BuildParam(1))
+	if err := r.DB.QueryRowContext(ctx, queryCount, productId).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	query := fmt.Sprintf("select id, productId, operation, actor, changedAt, beforeState, afterState, diff from product_audits where productId = %s order by changedAt desc, id desc limit %d offset %d", q.BuildParam(1), limit, offset)
+	rows, err := r.DB.QueryContext(ctx, query, productId)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	audits := make([]ProductAudit, 0)
+	for rows.Next() {
+		var audit ProductAudit
+		var operation string
+		var before, after, diff []byte
+		if err = rows.Scan(&audit.Id, &audit.ProductId, &operation, &audit.Actor, &audit.ChangedAt, &before, &after, &diff); err != nil {
+			return nil, 0, err
+		}
+		audit.Operation = AuditOperation(operation)
+		audit.Before = before
+		audit.After = after
+		if len(diff) > 0 {
+			if err = json.Unmarshal(diff, &audit.Diff); err != nil {
+				return nil, 0, err
+			}
+		}
+		audits = append(audits, audit)
+	}
+	return audits, total, rows.Err()
+}
+
+func nullJSON(data json.RawMessage) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// CreateBatch inserts products and their details with multi-row statements.
+func (r *ProductAdapter) CreateBatch(ctx context.Context, products []Product) (int64, error) {
+	return r.insertBatch(ctx, products, false)
+}
+
+// UpsertBatch inserts products, or replaces the ones which already exist; a replaced product is no longer deleted.
+func (r *ProductAdapter) UpsertBatch(ctx context.Context, products []Product) (int64, error) {
+	return r.insertBatch(ctx, products, true)
+}
+
+func (r *ProductAdapter) insertBatch(ctx context.Context, products []Product, upsert bool) (int64, error) {
+	tx := GetTx(ctx)
+	var rowsAffected int64
+	for start := 0; start < len(products); start += maxBatchRows {
+		chunk := products[start:minInt(start+maxBatchRows, len(products))]
+		generals := make([]interface{}, 0, len(chunk))
+		ids := make([]string, len(chunk))
+		var stocks []ProductDetails
+		for i := range chunk {
+			chunk[i].GeneralInfo.Version = 1
+			chunk[i].GeneralInfo.DeletedAt = nil
+			chunk[i].GeneralInfo.DeletedBy = nil
+			generals = append(generals, chunk[i].GeneralInfo)
+			ids[i] = chunk[i].GeneralInfo.Id
+			stocks = append(stocks, productStocks(&chunk[i])...)
+		}
+
+		queryGeneral, argsGeneral, columns := buildToInsertBatch("products", generals)
+		if upsert {
+			queryGeneral += upsertClause(productKeys, columns, "version = version + 1")
+		}
+		res, err := tx.ExecContext(ctx, queryGeneral, argsGeneral...)
+		if err != nil {
+			return -1, translateError(err, "")
+		}
+		n, _ := res.RowsAffected()
+		rowsAffected += n
+
+		// replaced products get the stocks they are written with
+		if upsert {
+			err = replaceStocks(ctx, tx, ids, stocks)
+		} else {
+			err = insertStocks(ctx, tx, stocks)
+		}
+		if err != nil {
+			return -1, err
+		}
+	}
+	return rowsAffected, nil
+}
+
+// DeleteBatch deletes products by ids, softly when the adapter is in soft delete mode.
+func (r *ProductAdapter) DeleteBatch(ctx context.Context, ids []string) (int64, error) {
+	tx := GetTx(ctx)
+	var rowsAffected int64
+	for start := 0; start < len(ids); start += maxBatchRows {
+		chunk := ids[start:minInt(start+maxBatchRows, len(ids))]
+		if r.SoftDelete {
+			query := fmt.Sprintf("update products set deletedAt = %s, deletedBy = %s, version = version + 1 where id in (%s) and deletedAt is null", buildInParams(1, 1), buildInParams(2, 1), buildInParams(3, len(chunk)))
+			args := append([]interface{}{time.Now(), ActorFromContext(ctx)}, toArgs(chunk)...)
+			res, err := tx.ExecContext(ctx, query, args...)
+			if err != nil {
+				return -1, translateError(err, "")
+			}
+			n, _ := res.RowsAffected()
+			rowsAffected += n
+			continue
+		}
+		queryDetails := fmt.Sprintf("delete from product_details where productID in (%s)", buildInParams(1, len(chunk)))
+		if _, err := tx.ExecContext(ctx, queryDetails, toArgs(chunk)...); err != nil {
+			return -1, translateError(err, "")
+		}
+		if err := deleteProductRows(ctx, tx, fmt.Sprintf("in (%s)", buildInParams(1, len(chunk))), toArgs(chunk)...); err != nil {
+			return -1, err
+		}
+		queryGeneral := fmt.Sprintf("delete from products where id in (%s)", buildInParams(1, len(chunk)))
+		res, err := tx.ExecContext(ctx, queryGeneral, toArgs(chunk)...)
+		if err != nil {
+			return -1, translateError(err, "")
+		}
+		n, _ := res.RowsAffected()
+		rowsAffected += n
+	}
+	return rowsAffected, nil
+}
+
+// LoadMany loads the products which exist and are not deleted, by id.
+// LoadManyForUpdate locks the rows of products, deleted or not, in the transaction of ctx, in the order of their ids
+// so that concurrent batches do not deadlock, then loads the products which are not deleted. The ids of the deleted
+// products are returned apart.
+func (r *ProductAdapter) LoadManyForUpdate(ctx context.Context, ids []string) (map[string]*Product, map[string]bool, error) {
+	tx := GetTx(ctx)
+	deleted := make(map[string]bool)
+	for start := 0; start < len(ids); start += maxBatchRows {
+		chunk := ids[start:minInt(start+maxBatchRows, len(ids))]
+		query := fmt.Sprintf("select id, deletedAt from products where id in (%s) order by id for update", buildInParams(1, len(chunk)))
+		rows, err := tx.QueryContext(ctx, query, toArgs(chunk)...)
+		if err != nil {
+			return nil, nil, translateError(err, "")
+		}
+		for rows.Next() {
+			var id string
+			var deletedAt *time.Time
+			if err = rows.Scan(&id, &deletedAt); err != nil {
+				rows.Close()
+				return nil, nil, err
+			}
+			if deletedAt != nil {
+				deleted[id] = true
+			}
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return nil, nil, translateError(err, "")
+		}
+	}
+	products, err := r.LoadMany(ctx, ids)
+	return products, deleted, err
+}
+
+func (r *ProductAdapter) LoadMany(ctx context.Context, ids []string) (map[string]*Product, error) {
+	exec := r.executor(ctx)
+	products := make(map[string]*Product, len(ids))
+	for start := 0; start < len(ids); start += maxBatchRows {
+		chunk := ids[start:minInt(start+maxBatchRows, len(ids))]
+		queryGeneral := fmt.Sprintf("select %s from products p%s where p.id in (%s) and p.deletedAt is null", productGeneralColumns, scheduledPriceJoin(buildInParams(1, 1), buildInParams(2, 1)), buildInParams(3, len(chunk)))
+		now := time.Now()
+		rows, err := exec.QueryContext(ctx, queryGeneral, append([]interface{}{now, now}, toArgs(chunk)...)...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var product Product
+			g := &product.GeneralInfo
+			if err = rows.Scan(productGeneralDest(g)...); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			products[g.Id] = &product
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+
+		stocks, err := loadStocks(ctx, exec, chunk)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range chunk {
+			if product, ok := products[id]; ok {
+				product.Stocks = stocks[id]
+				product.DetailInfo = SummarizeStocks(id, product.Stocks)
+			}
+		}
+	}
+	return products, nil
+}
+
+// upsertClause makes an insert of columns replace the row which has the same keys, with the MySQL
+// "on duplicate key update". The keys and the version are kept; extra assignments, such as a version increment, are appended.
+func upsertClause(keys []string, columns []string, extra ...string) string {
+	sets := make([]string, 0, len(columns)+len(extra))
+	for _, column := range columns {
+		if column != "version" && !contains(keys, column) {
+			sets = append(sets, column+" = values("+column+")")
+		}
+	}
+	return " on duplicate key update " + strings.Join(append(sets, extra...), ", ")
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
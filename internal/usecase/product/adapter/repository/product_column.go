@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"strings"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// productColumn is a column of the product aggregate in productQuery.from: the json name of its field, its expression,
+// and where it is scanned in a product. Nullable columns are coalesced, so that they scan into plain fields.
+type productColumn struct {
+	field   string
+	expr    string
+	details bool
+	dest    func(p *Product) interface{}
+}
+
+var productColumns = []productColumn{
+	{"id", "p.id", false, func(p *Product) interface{} { return &p.GeneralInfo.Id }},
+	{"productName", "coalesce(p.productName, '')", false, func(p *Product) interface{} { return &p.GeneralInfo.ProductName }},
+	{"description", "coalesce(p.description, '')", false, func(p *Product) interface{} { return &p.GeneralInfo.Description }},
+	{"price", effectivePrice, false, func(p *Product) interface{} { return &p.GeneralInfo.Price }},
+	{"basePrice", effectiveBasePrice, false, func(p *Product) interface{} { return &p.GeneralInfo.BasePrice }},
+	{"currency", "p.currency", false, func(p *Product) interface{} { return &p.GeneralInfo.Currency }},
+	{"status", "coalesce(p.status, '')", false, func(p *Product) interface{} { return &p.GeneralInfo.Status }},
+	{"available", "p.available", false, func(p *Product) interface{} { return &p.GeneralInfo.Available }},
+	{"version", "p.version", false, func(p *Product) interface{} { return &p.GeneralInfo.Version }},
+	{"deletedAt", "p.deletedAt", false, func(p *Product) interface{} { return &p.GeneralInfo.DeletedAt }},
+	{"deletedBy", "p.deletedBy", false, func(p *Product) interface{} { return &p.GeneralInfo.DeletedBy }},
+	{"productID", "coalesce(d.productID, '')", true, func(p *Product) interface{} { return &p.DetailInfo.ProductID }},
+	{"supplier", "coalesce(d.supplier, '')", true, func(p *Product) interface{} { return &p.DetailInfo.Supplier }},
+	{"storage", "coalesce(d.storage, '')", true, func(p *Product) interface{} { return &p.DetailInfo.Storage }},
+	{"inStockAmount", "coalesce(d.inStockAmount, 0)", true, func(p *Product) interface{} { return &p.DetailInfo.InStockAmount }},
+}
+
+// productGeneralColumns are the columns of the GeneralInfo of a product, in products p joined with its scheduled price
+// in effect, as scanned by productGeneralDest.
+const productGeneralColumns = "p.id, coalesce(p.productName, ''), coalesce(p.description, ''), " + effectivePrice + ", " + effectiveBasePrice +
+	", p.currency, coalesce(p.status, ''), p.available, p.version, p.deletedAt, p.deletedBy"
+
+func productGeneralDest(g *ProductGeneral) []interface{} {
+	return []interface{}{&g.Id, &g.ProductName, &g.Description, &g.Price, &g.BasePrice, &g.Currency, &g.Status, &g.Available, &g.Version, &g.DeletedAt, &g.DeletedBy}
+}
+
+// selectProductColumns returns the columns read for a projection: all of them when it is nil. Otherwise, the fields of
+// the projection, with the details when it sets Details, and the id and the version which are always read,
+// as are the fields in extra, such as the sort fields of a page token.
+func selectProductColumns(projection *ProductProjection, extra ...string) []productColumn {
+	if projection == nil {
+		return productColumns
+	}
+	fields := map[string]bool{"id": true, "version": true}
+	for _, field := range projection.Fields {
+		fields[field] = true
+	}
+	for _, field := range extra {
+		fields[field] = true
+	}
+	columns := make([]productColumn, 0, len(productColumns))
+	for _, column := range productColumns {
+		if fields[column.field] || (column.details && projection.Details) || (!column.details && len(projection.Fields) == 0) {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+func buildProductSelect(columns []productColumn) string {
+	exprs := make([]string, len(columns))
+	for i, column := range columns {
+		exprs[i] = column.expr
+	}
+	return "select " + strings.Join(exprs, ", ")
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// Export streams the products matching the filter, with their details, to write.
+// Rows are read one at a time, so the whole catalog is never held in memory.
+func (r *ProductAdapter) Export(ctx context.Context, filter *ProductFilter, write func(product *Product) error) error {
+	query, args := buildProductQuery(filter, r.Driver == driverMysql, productColumns)
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		product, err := scanProduct(rows, productColumns, hasTextSearch(filter))
+		if err != nil {
+			return err
+		}
+		if err = write(product); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
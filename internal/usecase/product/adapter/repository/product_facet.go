@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// productFacetColumns are the expressions of FacetFields in productFrom; as in productSelect, nulls are counted as "".
+// Suppliers and storages are the ones of the stocks, joined as s, so a product is counted once in each of its storages.
+var productFacetColumns = map[string]string{
+	"status":   "coalesce(p.status, '')",
+	"supplier": "coalesce(s.supplier, '')",
+	"storage":  "coalesce(s.storage, '')",
+	"currency": "p.currency",
+}
+
+const productStockJoin = " left join product_details s on s.productID = p.id"
+
+// Facets counts the products matching the filter by each value of each field, with one group by query per field.
+// The filter is the one of the list and its total; its paging and its page token are ignored.
+func (b *ProductSearchBuilder) Facets(ctx context.Context, filter *ProductFilter, fields []string) (map[string][]FacetCount, error) {
+	facets := make(map[string][]FacetCount, len(fields))
+	for _, field := range fields {
+		column, ok := productFacetColumns[field]
+		if !ok {
+			return nil, fmt.Errorf("'%s' is not a facet field", field)
+		}
+		pq := newProductQuery(filter, b.FullText, nil)
+		from := pq.from()
+		if field == "supplier" || field == "storage" {
+			from += productStockJoin
+		}
+		query := "select " + column + ", count(distinct p.id)" + from + pq.where() + " group by " + column + " order by count(distinct p.id) desc, " + column
+		counts, err := b.countBy(ctx, query, pq.args...)
+		if err != nil {
+			return nil, err
+		}
+		facets[field] = counts
+	}
+	return facets, nil
+}
+
+func (b *ProductSearchBuilder) countBy(ctx context.Context, query string, args ...interface{}) ([]FacetCount, error) {
+	rows, err := b.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := make([]FacetCount, 0)
+	for rows.Next() {
+		var count FacetCount
+		if err = rows.Scan(&count.Value, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
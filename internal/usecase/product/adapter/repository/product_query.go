@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	q "github.com/core-go/sql"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// productFrom is the product aggregate: products as p, joined with the summary of their stocks as d, which has one row
+// per product, as in SummarizeStocks: the total stock, the storage when there is one, and the supplier when it is the same.
+const productFrom = " from products p left join (select productID," +
+	" case when count(distinct coalesce(supplier, '')) = 1 then min(supplier) else '' end as supplier," +
+	" case when count(*) = 1 then min(storage) else '' end as storage," +
+	" sum(coalesce(inStockAmount, 0)) as inStockAmount" +
+	" from product_details group by productID) d on d.productID = p.id"
+
+// scheduledPriceJoin joins the scheduled price in effect as e; both parameters are the time it is in effect at.
+func scheduledPriceJoin(from string, to string) string {
+	return " left join product_prices e on e.id = (select s.id from product_prices s where s.productId = p.id" +
+		" and s.effectiveFrom <= " + from + " and (s.effectiveTo is null or s.effectiveTo > " + to + ")" +
+		" order by s.priority desc, s.effectiveFrom desc, s.createdAt desc, s.id desc limit 1)"
+}
+
+// effectivePrice is the price in effect: the scheduled one, or else the one the product is written with, which is
+// basePrice when a scheduled price which is no longer in effect is still stored in price.
+const effectivePrice = "coalesce(e.price, p.basePrice, p.price)"
+
+// effectiveBasePrice is the price the product is written with, when a scheduled price is in effect.
+const effectiveBasePrice = "case when e.id is null then null else coalesce(p.basePrice, p.price) end"
+
+// productQuery builds a query of the product aggregate. Its parameters are numbered in the order they are added,
+// so the clauses must be built in the order of the query.
+type productQuery struct {
+	filter   *ProductFilter
+	fullText bool
+	columns  []productColumn
+	now      time.Time
+	args     []interface{}
+}
+
+func newProductQuery(filter *ProductFilter, fullText bool, columns []productColumn) *productQuery {
+	return &productQuery{filter: filter, fullText: fullText, columns: columns, now: time.Now()}
+}
+
+func (b *productQuery) param(v interface{}) string {
+	b.args = append(b.args, v)
+	return q.BuildParam(len(b.args))
+}
+
+// buildProductQuery builds the query of the columns of the product aggregate from a filter, ordered by its sort then by id.
+// Soft deleted products are excluded unless the filter sets IncludeDeleted.
+func buildProductQuery(f *ProductFilter, fullText bool, columns []productColumn) (string, []interface{}) {
+	b := newProductQuery(f, fullText, columns)
+	query := b.selectClause() + b.from() + b.where()
+	return query + " order by " + buildProductOrderBy(parseProductSort(f)), b.args
+}
+
+// selectClause selects the columns, with the relevance of the text search when the filter has q.
+func (b *productQuery) selectClause() string {
+	if !hasTextSearch(b.filter) {
+		return buildProductSelect(b.columns)
+	}
+	return buildProductSelect(b.columns) + ", " + b.relevance() + " as relevance"
+}
+
+func hasTextSearch(f *ProductFilter) bool {
+	return f != nil && f.Filter != nil && len(strings.TrimSpace(f.Q)) > 0
+}
+
+// from is productFrom, with the scheduled prices in effect at the time of the query.
+func (b *productQuery) from() string {
+	return productFrom + scheduledPriceJoin(b.param(b.now), b.param(b.now))
+}
+
+// where builds the where clause of the filter, on the columns of from.
+func (b *productQuery) where() string {
+	f := b.filter
+	var where []string
+	if f == nil || !f.IncludeDeleted {
+		where = append(where, "p.deletedAt is null")
+	}
+	if f != nil {
+		if len(f.Id) > 0 {
+			where = append(where, "p.id = "+b.param(f.Id))
+		}
+		if hasTextSearch(f) {
+			where = append(where, b.textSearch())
+		}
+		if len(f.ProductName) > 0 {
+			where = append(where, b.textMatch("p.productName", f.ProductName, f.Match))
+		}
+		if len(f.Description) > 0 {
+			where = append(where, b.textMatch("p.description", f.Description, f.Match))
+		}
+		if len(f.Status) > 0 {
+			params := make([]string, len(f.Status))
+			for i, status := range f.Status {
+				params[i] = b.param(string(status))
+			}
+			where = append(where, "p.status in ("+strings.Join(params, ", ")+")")
+		}
+		if f.Price != nil {
+			if f.Price.Min != nil {
+				where = append(where, effectivePrice+" >= "+b.param(*f.Price.Min))
+			}
+			if f.Price.Max != nil {
+				where = append(where, effectivePrice+" <= "+b.param(*f.Price.Max))
+			}
+		}
+		if len(f.Supplier) > 0 {
+			where = append(where, "exists (select 1 from product_details s where s.productID = p.id and s.supplier = "+b.param(f.Supplier)+")")
+		}
+		if len(f.Storage) > 0 {
+			where = append(where, "exists (select 1 from product_details s where s.productID = p.id and s.storage = "+b.param(f.Storage)+")")
+		}
+		if f.Available != nil {
+			where = append(where, "p.available = "+b.param(*f.Available))
+		}
+		if f.InStockAmount != nil {
+			if f.InStockAmount.Min != nil {
+				where = append(where, "coalesce(d.inStockAmount, 0) >= "+b.param(*f.InStockAmount.Min))
+			}
+			if f.InStockAmount.Max != nil {
+				where = append(where, "coalesce(d.inStockAmount, 0) <= "+b.param(*f.InStockAmount.Max))
+			}
+		}
+	}
+	if len(where) == 0 {
+		return ""
+	}
+	return " where " + strings.Join(where, " and ")
+}
+
+// textMatch compares a text column to a value; the default match is a prefix. The case is ignored by the collation
+// of the column, which is not wrapped in a function, so that a prefix can use its index.
+// The wildcards of like are escaped in the value, so it is always compared literally.
+func (b *productQuery) textMatch(column string, value string, match TextMatch) string {
+	switch match {
+	case MatchEqual:
+		return column + " = " + b.param(value)
+	case MatchContains:
+		return column + " like " + b.param("%"+escapeLike(value)+"%")
+	default:
+		return column + " like " + b.param(escapeLike(value)+"%")
+	}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// scanProduct reads a row of columns, followed by the relevance when withRelevance is set.
+func scanProduct(rows *sql.Rows, columns []productColumn, withRelevance bool) (*Product, error) {
+	var product Product
+	dest := make([]interface{}, len(columns), len(columns)+1)
+	for i, column := range columns {
+		dest[i] = column.dest(&product)
+	}
+	if withRelevance {
+		product.Relevance = new(float64)
+		dest = append(dest, product.Relevance)
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
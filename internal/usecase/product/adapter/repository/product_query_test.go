@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/core-go/search"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func TestBuildProductQuery(t *testing.T) {
+	yes := true
+	selectFrom := "select p.id" + productFrom + scheduledPriceJoin("?", "?")
+	tests := []struct {
+		name   string
+		filter ProductFilter
+		where  string
+		args   []interface{}
+	}{
+		{"no criteria", ProductFilter{}, " where p.deletedAt is null", nil},
+		{"id", ProductFilter{Id: "P001"}, " where p.deletedAt is null and p.id = ?", []interface{}{"P001"}},
+		{"productName prefix by default", ProductFilter{ProductName: "Iron"}, " where p.deletedAt is null and p.productName like ?", []interface{}{"Iron%"}},
+		{"productName prefix", ProductFilter{ProductName: "Iron", Match: MatchPrefix}, " where p.deletedAt is null and p.productName like ?", []interface{}{"Iron%"}},
+		{"productName contains", ProductFilter{ProductName: "ron", Match: MatchContains}, " where p.deletedAt is null and p.productName like ?", []interface{}{"%ron%"}},
+		{"productName equal", ProductFilter{ProductName: "Iron Man", Match: MatchEqual}, " where p.deletedAt is null and p.productName = ?", []interface{}{"Iron Man"}},
+		{"description prefix", ProductFilter{Description: "toy", Match: MatchPrefix}, " where p.deletedAt is null and p.description like ?", []interface{}{"toy%"}},
+		{"description contains", ProductFilter{Description: "oy", Match: MatchContains}, " where p.deletedAt is null and p.description like ?", []interface{}{"%oy%"}},
+		{"description equal", ProductFilter{Description: "toys", Match: MatchEqual}, " where p.deletedAt is null and p.description = ?", []interface{}{"toys"}},
+		{"status in list", ProductFilter{Status: []ProductStatus{StatusActive, StatusDiscontinued}}, " where p.deletedAt is null and p.status in (?, ?)", []interface{}{"active", "discontinued"}},
+		{"price range", ProductFilter{Price: &search.NumberRange{Min: float(100), Max: float(200)}}, " where p.deletedAt is null and " + effectivePrice + " >= ? and " + effectivePrice + " <= ?", []interface{}{100.0, 200.0}},
+		{"price min", ProductFilter{Price: &search.NumberRange{Min: float(100)}}, " where p.deletedAt is null and " + effectivePrice + " >= ?", []interface{}{100.0}},
+		{"inStockAmount range", ProductFilter{InStockAmount: &search.NumberRange{Min: float(1), Max: float(10)}}, " where p.deletedAt is null and coalesce(d.inStockAmount, 0) >= ? and coalesce(d.inStockAmount, 0) <= ?", []interface{}{1.0, 10.0}},
+		{"inStockAmount max", ProductFilter{InStockAmount: &search.NumberRange{Max: float(0)}}, " where p.deletedAt is null and coalesce(d.inStockAmount, 0) <= ?", []interface{}{0.0}},
+		{"supplier", ProductFilter{Supplier: "LEGO inc."}, " where p.deletedAt is null and exists (select 1 from product_details s where s.productID = p.id and s.supplier = ?)", []interface{}{"LEGO inc."}},
+		{"storage", ProductFilter{Storage: "north"}, " where p.deletedAt is null and exists (select 1 from product_details s where s.productID = p.id and s.storage = ?)", []interface{}{"north"}},
+		{"available", ProductFilter{Available: &yes}, " where p.deletedAt is null and p.available = ?", []interface{}{true}},
+		{"includeDeleted", ProductFilter{IncludeDeleted: true}, "", nil},
+		{"includeDeleted with id", ProductFilter{IncludeDeleted: true, Id: "P001"}, " where p.id = ?", []interface{}{"P001"}},
+		{"criteria in order", ProductFilter{Id: "P001", ProductName: "Iron", Storage: "north", Available: &yes},
+			" where p.deletedAt is null and p.id = ? and p.productName like ? and exists (select 1 from product_details s where s.productID = p.id and s.storage = ?) and p.available = ?",
+			[]interface{}{"P001", "Iron%", "north", true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := buildProductQuery(&tt.filter, false, productColumns[:1])
+			if expected := selectFrom + tt.where + " order by p.id asc"; query != expected {
+				t.Errorf("query\n got: %s\nwant: %s", query, expected)
+			}
+			assertProductArgs(t, args, tt.args)
+		})
+	}
+}
+
+func TestTextMatchEscapesLikeWildcards(t *testing.T) {
+	tests := []struct {
+		name  string
+		match TextMatch
+		value string
+		where string
+		arg   string
+	}{
+		{"prefix", MatchPrefix, `50%_off\`, "p.productName like ?", `50\%\_off\\%`},
+		{"contains", MatchContains, `50%_off\`, "p.productName like ?", `%50\%\_off\\%`},
+		{"equal is not escaped", MatchEqual, `50%_off\`, "p.productName = ?", `50%_off\`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := buildProductQuery(&ProductFilter{ProductName: tt.value, Match: tt.match}, false, productColumns[:1])
+			expected := "select p.id" + productFrom + scheduledPriceJoin("?", "?") + " where p.deletedAt is null and " + tt.where + " order by p.id asc"
+			if query != expected {
+				t.Errorf("query\n got: %s\nwant: %s", query, expected)
+			}
+			assertProductArgs(t, args, []interface{}{tt.arg})
+		})
+	}
+}
+
+// assertProductArgs checks the arguments of a product query: the time of the scheduled prices twice, then the criteria.
+func assertProductArgs(t *testing.T, args []interface{}, expected []interface{}) {
+	t.Helper()
+	if len(args) < 2 {
+		t.Fatalf("args %v have no time of the scheduled prices", args)
+	}
+	from, ok := args[0].(time.Time)
+	if !ok || args[1] != from {
+		t.Errorf("args %v do not start with the time of the scheduled prices twice", args[:2])
+	}
+	got := args[2:]
+	if len(got) == 0 && len(expected) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("args\n got: %#v\nwant: %#v", got, expected)
+	}
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// ProductSearchBuilder searches the product aggregate, so that the filter can use the columns of product_details
+// and each item of the result has its details.
+type ProductSearchBuilder struct {
+	DB       *sql.DB
+	FullText bool
+}
+
+// NewProductSearchBuilder uses the fulltext index of products for q when the driver is mysql.
+func NewProductSearchBuilder(db *sql.DB, driver string) *ProductSearchBuilder {
+	return &ProductSearchBuilder{DB: db, FullText: driver == driverMysql}
+}
+
+// Search is the find function of search.SearchHandler: filter is a *ProductFilter, results a *[]Product,
+// and options[0] is the offset. It returns the total of the matching products;
+// in cursor paging, it returns the token of the next page instead, which is empty on the last page.
+func (b *ProductSearchBuilder) Search(ctx context.Context, filter interface{}, results interface{}, limit int64, options ...int64) (int64, string, error) {
+	f, ok := filter.(*ProductFilter)
+	if !ok {
+		return 0, "", fmt.Errorf("filter must be a *ProductFilter, not %T", filter)
+	}
+	products, ok := results.(*[]Product)
+	if !ok {
+		return 0, "", fmt.Errorf("results must be a *[]Product, not %T", results)
+	}
+	if f.CursorPaging() {
+		return b.searchAfter(ctx, f, products, limit)
+	}
+	var offset int64
+	if len(options) > 0 && options[0] > 0 {
+		offset = options[0]
+	}
+
+	count := newProductQuery(f, b.FullText, nil)
+	var total int64
+	if err := b.DB.QueryRowContext(ctx, "select count(*)"+count.from()+count.where(), count.args...).Scan(&total); err != nil {
+		return 0, "", err
+	}
+	if total == 0 || offset >= total {
+		return total, "", nil
+	}
+
+	columns := selectProductColumns(f.Projection())
+	query, args := buildProductQuery(f, b.FullText, columns)
+	if limit > 0 {
+		query += fmt.Sprintf(" limit %d offset %d", limit, offset)
+	}
+	if err := b.query(ctx, products, columns, hasTextSearch(f), query, args...); err != nil {
+		return 0, "", err
+	}
+	return total, "", nil
+}
+
+// searchAfter reads the page after the token of the filter, ordered by the sort of the filter then by id.
+// It reads one more row than limit, to know if there is a next page.
+func (b *ProductSearchBuilder) searchAfter(ctx context.Context, f *ProductFilter, products *[]Product, limit int64) (int64, string, error) {
+	sorts := parseProductSort(f)
+	sortFields := make([]string, len(sorts))
+	for i, s := range sorts {
+		if s.key.value == nil {
+			return 0, "", &ValidationError{Errors: []ErrorMessage{{Field: "sort", Code: "invalid", Message: "relevance cannot be sorted with cursor paging"}}}
+		}
+		sortFields[i] = s.field
+	}
+	// the sort fields are read even if they are not in the projection, to make the page token
+	columns := selectProductColumns(f.Projection(), sortFields...)
+	pq := newProductQuery(f, b.FullText, columns)
+	selectClause := pq.selectClause()
+	from := pq.from()
+	where := pq.where()
+	if len(f.NextPageToken) > 0 {
+		keys, err := decodePageToken(f.NextPageToken, f.Sort, sorts)
+		if err != nil {
+			return 0, "", err
+		}
+		if len(where) == 0 {
+			where = " where " + pq.keyset(sorts, keys)
+		} else {
+			where += " and " + pq.keyset(sorts, keys)
+		}
+	}
+	query := selectClause + from + where + " order by " + buildProductOrderBy(sorts) + fmt.Sprintf(" limit %d", limit+1)
+	if err := b.query(ctx, products, columns, hasTextSearch(f), query, pq.args...); err != nil {
+		return 0, "", err
+	}
+	if int64(len(*products)) <= limit {
+		return 0, "", nil
+	}
+	*products = (*products)[:limit]
+	return 0, encodePageToken(f.Sort, sorts, &(*products)[limit-1]), nil
+}
+
+func (b *ProductSearchBuilder) query(ctx context.Context, products *[]Product, columns []productColumn, withRelevance bool, query string, args ...interface{}) error {
+	rows, err := b.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		product, err := scanProduct(rows, columns, withRelevance)
+		if err != nil {
+			return err
+		}
+		*products = append(*products, *product)
+	}
+	return rows.Err()
+}
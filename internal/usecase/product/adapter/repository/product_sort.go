@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// productSortKey is a field accepted in "sort": its expression in productQuery.from, and its value in a product.
+// Nullable columns are coalesced as in productColumns, so that a page token compares the same values as the order by.
+// The relevance of a text search has no value, because it cannot be compared again in a page token.
+type productSortKey struct {
+	column string
+	value  func(product *Product) interface{}
+}
+
+var productSortKeys = map[string]productSortKey{
+	"id":            {"p.id", func(p *Product) interface{} { return p.GeneralInfo.Id }},
+	"productName":   {"coalesce(p.productName, '')", func(p *Product) interface{} { return p.GeneralInfo.ProductName }},
+	"description":   {"coalesce(p.description, '')", func(p *Product) interface{} { return p.GeneralInfo.Description }},
+	"price":         {effectivePrice, func(p *Product) interface{} { return int64(p.GeneralInfo.Price) }},
+	"currency":      {"p.currency", func(p *Product) interface{} { return string(p.GeneralInfo.Currency) }},
+	"status":        {"coalesce(p.status, '')", func(p *Product) interface{} { return string(p.GeneralInfo.Status) }},
+	"version":       {"p.version", func(p *Product) interface{} { return p.GeneralInfo.Version }},
+	"supplier":      {"coalesce(d.supplier, '')", func(p *Product) interface{} { return p.DetailInfo.Supplier }},
+	"storage":       {"coalesce(d.storage, '')", func(p *Product) interface{} { return p.DetailInfo.Storage }},
+	"inStockAmount": {"coalesce(d.inStockAmount, 0)", func(p *Product) interface{} { return int64(p.DetailInfo.InStockAmount) }},
+}
+
+var relevanceSortKey = productSortKey{column: "relevance"}
+
+type productSort struct {
+	field string
+	key   productSortKey
+	desc  bool
+}
+
+// parseProductSort reads a sort expression such as "price,-id". Unknown fields are ignored, so the expression
+// cannot inject SQL, and id is appended when missing, so that the order is total.
+// With a text search, "relevance" is accepted too, and the default sort is "-relevance".
+func parseProductSort(f *ProductFilter) []productSort {
+	var sorts []productSort
+	hasId := false
+	if f != nil && f.Filter != nil {
+		for _, field := range strings.Split(f.Sort, ",") {
+			field = strings.TrimSpace(field)
+			desc := strings.HasPrefix(field, "-")
+			field = strings.TrimLeft(field, "+-")
+			if key, ok := productSortKeys[field]; ok {
+				sorts = append(sorts, productSort{field: field, key: key, desc: desc})
+				hasId = hasId || field == "id"
+			} else if field == "relevance" && hasTextSearch(f) {
+				sorts = append(sorts, productSort{field: field, key: relevanceSortKey, desc: desc})
+			}
+		}
+	}
+	if len(sorts) == 0 && hasTextSearch(f) {
+		sorts = append(sorts, productSort{field: "relevance", key: relevanceSortKey, desc: true})
+	}
+	if !hasId {
+		sorts = append(sorts, productSort{field: "id", key: productSortKeys["id"]})
+	}
+	return sorts
+}
+
+func buildProductOrderBy(sorts []productSort) string {
+	orders := make([]string, len(sorts))
+	for i, s := range sorts {
+		if s.desc {
+			orders[i] = s.key.column + " desc"
+		} else {
+			orders[i] = s.key.column + " asc"
+		}
+	}
+	return strings.Join(orders, ", ")
+}
+
+// pageToken is the opaque nextPageToken of a cursor search: the sort it was made for, and the sort values of the last product of the page.
+type pageToken struct {
+	Sort string        `json:"s"`
+	Keys []interface{} `json:"k"`
+}
+
+func encodePageToken(sort string, sorts []productSort, last *Product) string {
+	token := pageToken{Sort: sort, Keys: make([]interface{}, len(sorts))}
+	for i, s := range sorts {
+		token.Keys[i] = s.key.value(last)
+	}
+	data, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageToken(s string, sort string, sorts []productSort) ([]interface{}, error) {
+	invalid := &ValidationError{Errors: []ErrorMessage{{Field: "nextPageToken", Code: "invalid", Message: "nextPageToken is invalid, or was made for another sort"}}}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, invalid
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	var token pageToken
+	if err = decoder.Decode(&token); err != nil || token.Sort != sort || len(token.Keys) != len(sorts) {
+		return nil, invalid
+	}
+	for i, key := range token.Keys {
+		if n, ok := key.(json.Number); ok {
+			if token.Keys[i], err = n.Int64(); err != nil {
+				return nil, invalid
+			}
+		}
+	}
+	return token.Keys, nil
+}
+
+// keyset builds the condition of the rows after keys in the order of sorts, such as "(a > ?) or (a = ? and b < ?)".
+func (b *productQuery) keyset(sorts []productSort, keys []interface{}) string {
+	param := b.param
+	ors := make([]string, len(sorts))
+	for i, s := range sorts {
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, sorts[j].key.column+" = "+param(keys[j]))
+		}
+		operator := " > "
+		if s.desc {
+			operator = " < "
+		}
+		ands = append(ands, s.key.column+operator+param(keys[i]))
+		ors[i] = "(" + strings.Join(ands, " and ") + ")"
+	}
+	return "(" + strings.Join(ors, " or ") + ")"
+}
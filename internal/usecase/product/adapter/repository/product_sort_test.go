@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	"github.com/core-go/search"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+func sortsOf(sort string) []productSort {
+	return parseProductSort(&ProductFilter{Filter: &search.Filter{Sort: sort}})
+}
+
+func TestPageToken(t *testing.T) {
+	last := &Product{
+		GeneralInfo: ProductGeneral{Id: "P001", ProductName: "Iron Man", Price: 100000},
+		DetailInfo:  ProductDetails{InStockAmount: 7},
+	}
+	sorts := sortsOf("-price,productName,inStockAmount")
+	token := encodePageToken("-price,productName,inStockAmount", sorts, last)
+	keys, err := decodePageToken(token, "-price,productName,inStockAmount", sorts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// integers come back as int64, as the values of the sort keys, rather than as json numbers
+	if expected := []interface{}{int64(100000), "Iron Man", int64(7), "P001"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("keys\n got: %#v\nwant: %#v", keys, expected)
+	}
+
+	encode := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+	invalid := []struct {
+		name  string
+		token string
+		sort  string
+	}{
+		{"another sort", token, "price,productName,inStockAmount"},
+		{"not base64", "%%%", "-price,productName,inStockAmount"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"id","k":["P001"]}`)), "id"},
+		{"not json", encode("P001"), "id"},
+		{"fewer keys than the sort", encode(`{"s":"-price","k":[100000]}`), "-price"},
+		{"more keys than the sort", encode(`{"s":"id","k":["P001","P002"]}`), "id"},
+		{"number which is not an integer", encode(`{"s":"price","k":[1.5,"P001"]}`), "price"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodePageToken(tt.token, tt.sort, sortsOf(tt.sort))
+			e, ok := err.(*ValidationError)
+			if !ok || len(e.Errors) != 1 || e.Errors[0].Field != "nextPageToken" {
+				t.Errorf("error = %#v, want a validation error of nextPageToken", err)
+			}
+		})
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	tests := []struct {
+		name      string
+		sort      string
+		keys      []interface{}
+		condition string
+		args      []interface{}
+	}{
+		{"id", "", []interface{}{"P001"}, "((p.id > ?))", []interface{}{"P001"}},
+		{"descending id", "-id", []interface{}{"P001"}, "((p.id < ?))", []interface{}{"P001"}},
+		{"price then id", "-price", []interface{}{int64(100000), "P001"},
+			"((" + effectivePrice + " < ?) or (" + effectivePrice + " = ? and p.id > ?))",
+			[]interface{}{int64(100000), int64(100000), "P001"}},
+		{"three keys", "storage,-inStockAmount", []interface{}{"north", int64(7), "P001"},
+			"((coalesce(d.storage, '') > ?) or (coalesce(d.storage, '') = ? and coalesce(d.inStockAmount, 0) < ?)" +
+				" or (coalesce(d.storage, '') = ? and coalesce(d.inStockAmount, 0) = ? and p.id > ?))",
+			[]interface{}{"north", "north", int64(7), "north", int64(7), "P001"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newProductQuery(&ProductFilter{}, false, nil)
+			if condition := b.keyset(sortsOf(tt.sort), tt.keys); condition != tt.condition {
+				t.Errorf("condition\n got: %s\nwant: %s", condition, tt.condition)
+			}
+			if !reflect.DeepEqual(b.args, tt.args) {
+				t.Errorf("args\n got: %#v\nwant: %#v", b.args, tt.args)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	q "github.com/core-go/sql"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// productStocks returns the stocks a product is written with: its stocks, or its DetailInfo when it has none.
+func productStocks(product *Product) []ProductDetails {
+	stocks := product.Stocks
+	if len(stocks) == 0 && checkReqProductDetails(product.DetailInfo) == nil {
+		stocks = []ProductDetails{product.DetailInfo}
+	}
+	for i := range stocks {
+		stocks[i].ProductID = product.GeneralInfo.Id
+	}
+	return stocks
+}
+
+// insertStocks inserts stocks with multi-row statements.
+func insertStocks(ctx context.Context, exec executor, stocks []ProductDetails) error {
+	for start := 0; start < len(stocks); start += maxBatchRows {
+		chunk := stocks[start:minInt(start+maxBatchRows, len(stocks))]
+		rows := make([]interface{}, len(chunk))
+		for i := range chunk {
+			rows[i] = chunk[i]
+		}
+		query, args, _ := buildToInsertBatch("product_details", rows)
+		if _, err := exec.ExecContext(ctx, query, args...); err != nil {
+			return translateError(err, chunk[0].ProductID)
+		}
+	}
+	return nil
+}
+
+// replaceStocks replaces all the stocks of products by the given stocks.
+func replaceStocks(ctx context.Context, exec executor, ids []string, stocks []ProductDetails) error {
+	query := fmt.Sprintf("delete from product_details where productID in (%s)", buildInParams(1, len(ids)))
+	if _, err := exec.ExecContext(ctx, query, toArgs(ids)...); err != nil {
+		return translateError(err, "")
+	}
+	return insertStocks(ctx, exec, stocks)
+}
+
+// loadStocks loads the stocks of products by id, ordered by storage.
+func loadStocks(ctx context.Context, exec executor, ids []string) (map[string][]ProductDetails, error) {
+	query := fmt.Sprintf("select productID, coalesce(supplier, ''), storage, coalesce(inStockAmount, 0) from product_details where productID in (%s) order by productID, storage", buildInParams(1, len(ids)))
+	rows, err := exec.QueryContext(ctx, query, toArgs(ids)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	stocks := make(map[string][]ProductDetails)
+	for rows.Next() {
+		var d ProductDetails
+		if err = rows.Scan(&d.ProductID, &d.Supplier, &d.Storage, &d.InStockAmount); err != nil {
+			return nil, err
+		}
+		stocks[d.ProductID] = append(stocks[d.ProductID], d)
+	}
+	return stocks, rows.Err()
+}
+
+// AdjustStock adds delta to the stock of a product in a storage, with a conditional update, so that concurrent
+// adjustments cannot make the stock negative. A positive delta creates the stock of a storage the product is not in.
+func (r *ProductAdapter) AdjustStock(ctx context.Context, id string, storage string, delta int) (int64, error) {
+	tx := GetTx(ctx)
+	query := fmt.Sprintf("update product_details set inStockAmount = coalesce(inStockAmount, 0) + %s where productID = %s and storage = %s and coalesce(inStockAmount, 0) + %s >= 0",
+		q.BuildParam(1), q.BuildParam(2), q.BuildParam(3), q.BuildParam(4))
+	res, err := tx.ExecContext(ctx, query, delta, id, storage, delta)
+	if err != nil {
+		return -1, translateError(err, id)
+	}
+	n, err := res.RowsAffected()
+	if err != nil || n > 0 || delta < 0 {
+		return n, err
+	}
+	var exists int
+	queryExists := fmt.Sprintf("select count(*) from product_details where productID = %s and storage = %s", q.BuildParam(1), q.BuildParam(2))
+	if err = tx.QueryRowContext(ctx, queryExists, id, storage).Scan(&exists); err != nil || exists > 0 {
+		// mysql does not count the rows which are not changed, as with a delta of 0
+		return int64(exists), err
+	}
+	if err = insertStocks(ctx, tx, []ProductDetails{{ProductID: id, Storage: storage, InStockAmount: delta}}); err != nil {
+		return -1, err
+	}
+	return 1, nil
+}
+
+// LoadStocks loads the stocks of a product, deleted or not, ordered by storage.
+func (r *ProductAdapter) LoadStocks(ctx context.Context, id string) ([]ProductDetails, error) {
+	stocks, err := loadStocks(ctx, r.executor(ctx), []string{id})
+	if err != nil {
+		return nil, err
+	}
+	return stocks[id], nil
+}
+
+// UpdateAvailability sets whether a product which is not deleted is in stock, and increments its version.
+func (r *ProductAdapter) UpdateAvailability(ctx context.Context, id string, available bool) (int64, error) {
+	tx := GetTx(ctx)
+	query := fmt.Sprintf("update products set available = %s, version = version + 1 where id = %s and deletedAt is null", q.BuildParam(1), q.BuildParam(2))
+	res, err := tx.ExecContext(ctx, query, available, id)
+	if err != nil {
+		return -1, translateError(err, id)
+	}
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		return 0, &NotFoundError{Resource: productResource, Id: id}
+	}
+	return n, err
+}
@@ -0,0 +1,46 @@
+package repository
+
+import "strings"
+
+const driverMysql = "mysql"
+
+// fullTextColumns are the columns of the fulltext index of products, in the order of the index.
+const fullTextColumns = "p.productName, p.description"
+
+// textSearch is the condition of q. On mysql, it uses the fulltext index of products in natural language mode;
+// on other drivers, every word of q must be in productName or description.
+func (b *productQuery) textSearch() string {
+	if b.fullText {
+		return "match(" + fullTextColumns + ") against (" + b.param(b.filter.Q) + " in natural language mode)"
+	}
+	words := strings.Fields(strings.ToLower(b.filter.Q))
+	conditions := make([]string, len(words))
+	for i, word := range words {
+		pattern := "%" + escapeLike(word) + "%"
+		conditions[i] = "(lower(coalesce(p.productName, '')) like " + b.param(pattern) + " or lower(coalesce(p.description, '')) like " + b.param(pattern) + ")"
+	}
+	if len(conditions) == 0 {
+		return "1 = 1"
+	}
+	return strings.Join(conditions, " and ")
+}
+
+// relevance is the score of a product for q. On mysql, it is the score of the fulltext index;
+// on other drivers, it counts 2 for each word in productName and 1 for each word in description.
+func (b *productQuery) relevance() string {
+	if b.fullText {
+		return "match(" + fullTextColumns + ") against (" + b.param(b.filter.Q) + " in natural language mode)"
+	}
+	words := strings.Fields(strings.ToLower(b.filter.Q))
+	scores := make([]string, 0, 2*len(words))
+	for _, word := range words {
+		pattern := "%" + escapeLike(word) + "%"
+		scores = append(scores,
+			"case when lower(coalesce(p.productName, '')) like "+b.param(pattern)+" then 2 else 0 end",
+			"case when lower(coalesce(p.description, '')) like "+b.param(pattern)+" then 1 else 0 end")
+	}
+	if len(scores) == 0 {
+		return "0"
+	}
+	return "(" + strings.Join(scores, " + ") + ")"
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	q "github.com/core-go/sql"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+const reservationResource = "reservation"
+
+func NewReservationAdapter(db *sql.DB) *ReservationAdapter {
+	return &ReservationAdapter{DB: db}
+}
+
+// ReservationAdapter stores the reservations of products in product_reservations.
+type ReservationAdapter struct {
+	DB *sql.DB
+}
+
+// Insert writes the reservation in the transaction of the context, with the change of the stock it holds.
+func (r *ReservationAdapter) Insert(ctx context.Context, reservation *Reservation) error {
+	query := fmt.Sprintf("insert into product_reservations (id, productId, storage, quantity, status, actor, createdAt, expiresAt) values (%s, %s, %s, %s, %s, %s, %s, %s)",
+		q.BuildParam(1), q.BuildParam(2), q.BuildParam(3), q.BuildParam(4), q.BuildParam(5), q.BuildParam(6), q.BuildParam(7), q.BuildParam(8))
+	_, err := GetTx(ctx).ExecContext(ctx, query, reservation.Id, reservation.ProductId, reservation.Storage, reservation.Quantity, string(reservation.Status), reservation.Actor, reservation.CreatedAt, reservation.ExpiresAt)
+	return err
+}
+
+func (r *ReservationAdapter) Load(ctx context.Context, id string) (*Reservation, error) {
+	query := fmt.Sprintf("select id, productId, storage, quantity, status, actor, createdAt, expiresAt, updatedAt from product_reservations where id = %s", q.BuildParam(1))
+	var reservation Reservation
+	err := r.executor(ctx).QueryRowContext(ctx, query, id).Scan(&reservation.Id, &reservation.ProductId, &reservation.Storage, &reservation.Quantity, &reservation.Status, &reservation.Actor, &reservation.CreatedAt, &reservation.ExpiresAt, &reservation.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: reservationResource, Id: id}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+func (r *ReservationAdapter) UpdateStatus(ctx context.Context, id string, from ReservationStatus, to ReservationStatus) (int64, error) {
+	query := fmt.Sprintf("update product_reservations set status = %s, updatedAt = %s where id = %s and status = %s", q.BuildParam(1), q.BuildParam(2), q.BuildParam(3), q.BuildParam(4))
+	res, err := GetTx(ctx).ExecContext(ctx, query, string(to), time.Now(), id, string(from))
+	if err != nil {
+		return -1, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *ReservationAdapter) Reserved(ctx context.Context, productId string) (int, error) {
+	query := fmt.Sprintf("select coalesce(sum(quantity), 0) from product_reservations where productId = %s and status = %s", q.BuildParam(1), q.BuildParam(2))
+	var reserved int
+	err := r.executor(ctx).QueryRowContext(ctx, query, productId, string(ReservationHeld)).Scan(&reserved)
+	return reserved, err
+}
+
+func (r *ReservationAdapter) Expired(ctx context.Context, before time.Time, limit int) ([]Reservation, error) {
+	query := fmt.Sprintf("select id, productId, storage, quantity, status, actor, createdAt, expiresAt, updatedAt from product_reservations where status = %s and expiresAt < %s order by expiresAt limit %d", q.BuildParam(1), q.BuildParam(2), limit)
+	rows, err := r.DB.QueryContext(ctx, query, string(ReservationHeld), before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var reservations []Reservation
+	for rows.Next() {
+		var reservation Reservation
+		if err = rows.Scan(&reservation.Id, &reservation.ProductId, &reservation.Storage, &reservation.Quantity, &reservation.Status, &reservation.Actor, &reservation.CreatedAt, &reservation.ExpiresAt, &reservation.UpdatedAt); err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, reservation)
+	}
+	return reservations, rows.Err()
+}
+
+// executor returns the transaction of the context, so reads see the writes of the current transaction.
+func (r *ReservationAdapter) executor(ctx context.Context) executor {
+	if tx := GetTx(ctx); tx != nil {
+		return tx
+	}
+	return r.DB
+}
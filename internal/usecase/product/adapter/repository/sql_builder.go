@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	q "github.com/core-go/sql"
+)
+
+// toColumns maps the fields of a model to their database columns, using the gorm column tag.
+func toColumns(model interface{}) map[string]interface{} {
+	v := reflect.Indirect(reflect.ValueOf(model))
+	t := v.Type()
+	columns := make(map[string]interface{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if column, ok := columnName(t.Field(i)); ok {
+			columns[column] = v.Field(i).Interface()
+		}
+	}
+	return columns
+}
+
+func columnName(field reflect.StructField) (string, bool) {
+	tag, ok := field.Tag.Lookup("gorm")
+	if !ok {
+		return "", false
+	}
+	for _, part := range strings.Split(tag, ";") {
+		if strings.HasPrefix(part, "column:") {
+			return strings.TrimPrefix(part, "column:"), true
+		}
+	}
+	return "", false
+}
+
+// readOnlyColumns are maintained by the repository and never taken from the request.
+var readOnlyColumns = map[string]bool{"id": true, "version": true, "deletedAt": true, "deletedBy": true}
+
+// buildToUpdateWithVersion builds an update by id of a row which is not soft deleted, and increments its version.
+// When version is positive, the row is only updated if it still has that version.
+func buildToUpdateWithVersion(table string, columns map[string]interface{}, id string, version int64) (string, []interface{}) {
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		if !readOnlyColumns[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	sets := make([]string, 0, len(names)+1)
+	args := make([]interface{}, 0, len(names)+2)
+	for i, name := range names {
+		sets = append(sets, fmt.Sprintf("%s = %s", name, q.BuildParam(i+1)))
+		args = append(args, columns[name])
+	}
+	sets = append(sets, "version = version + 1")
+	args = append(args, id)
+	query := fmt.Sprintf("update %s set %s where id = %s and deletedAt is null", table, strings.Join(sets, ", "), q.BuildParam(len(args)))
+	if version > 0 {
+		args = append(args, version)
+		query += fmt.Sprintf(" and version = %s", q.BuildParam(len(args)))
+	}
+	return query, args
+}
+
+// toVersion reads a version from a json patch value.
+func toVersion(v interface{}) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	case float64:
+		return int64(n)
+	case json.Number:
+		i, _ := n.Int64()
+		return i
+	case string:
+		i, _ := strconv.ParseInt(n, 10, 64)
+		return i
+	}
+	return 0
+}
+
+// maxBatchRows keeps the placeholders of a multi-row statement below the limit of the driver.
+const maxBatchRows = 1000
+
+// buildToInsertBatch builds a multi-row insert of models, which must all be of the same type.
+// The returned columns are in the order of the values of each row.
+func buildToInsertBatch(table string, models []interface{}) (string, []interface{}, []string) {
+	if len(models) == 0 {
+		return "", nil, nil
+	}
+	first := toColumns(models[0])
+	columns := make([]string, 0, len(first))
+	for column := range first {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	rows := make([]string, 0, len(models))
+	args := make([]interface{}, 0, len(models)*len(columns))
+	for _, model := range models {
+		values := toColumns(model)
+		params := make([]string, len(columns))
+		for i, column := range columns {
+			args = append(args, values[column])
+			params[i] = q.BuildParam(len(args))
+		}
+		rows = append(rows, "("+strings.Join(params, ", ")+")")
+	}
+	query := fmt.Sprintf("insert into %s (%s) values %s", table, strings.Join(columns, ", "), strings.Join(rows, ", "))
+	return query, args, columns
+}
+
+// buildInParams returns the placeholders of an "in" clause, numbered from start.
+func buildInParams(start int, n int) string {
+	params := make([]string, n)
+	for i := range params {
+		params[i] = q.BuildParam(start + i)
+	}
+	return strings.Join(params, ", ")
+}
+
+func toArgs(ids []string) []interface{} {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
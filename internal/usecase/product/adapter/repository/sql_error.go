@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	q "github.com/core-go/sql"
+	"github.com/go-sql-driver/mysql"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+const (
+	mysqlDuplicateEntry  = 1062
+	mysqlRowIsReferenced = 1451
+	mysqlNoReferencedRow = 1452
+	mysqlDeadlock        = 1213
+)
+
+// translateError converts driver errors into domain errors, so callers do not depend on the database.
+// Batch statements pass an empty id, and keep the message of the database, which tells the offending key.
+func translateError(err error, id string) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case mysqlDuplicateEntry:
+			message := mysqlErr.Message
+			if len(id) > 0 {
+				message = fmt.Sprintf("%s '%s' already exists", productResource, id)
+			}
+			return &ConflictError{Resource: productResource, Id: id, Code: CodeDuplicateKey, Message: message}
+		case mysqlRowIsReferenced, mysqlNoReferencedRow:
+			return &ConflictError{Resource: productResource, Id: id, Code: CodeForeignKey, Message: mysqlErr.Message}
+		case mysqlDeadlock:
+			// concurrent writes of a product which does not exist yet wait for each other on the lock of its key
+			message := mysqlErr.Message
+			if len(id) > 0 {
+				message = fmt.Sprintf("%s '%s' has been written by another request", productResource, id)
+			}
+			return &ConflictError{Resource: productResource, Id: id, Code: CodeConcurrentWrite, Message: message}
+		}
+	}
+	return err
+}
+
+func deletedError(id string) error {
+	return &ConflictError{Resource: productResource, Id: id, Code: CodeDeleted, Message: fmt.Sprintf("%s '%s' is deleted, and must be restored first", productResource, id)}
+}
+
+// checkVersion verifies a versioned update and returns the new version of the row.
+// Zero affected rows means either the row does not exist or its version is stale.
+func checkVersion(ctx context.Context, tx *sql.Tx, res sql.Result, id string, expected int64) (int64, error) {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	current, err := currentVersion(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 && expected > 0 && current != expected {
+		return 0, &VersionMismatchError{Resource: productResource, Id: id}
+	}
+	return current, nil
+}
+
+// currentVersion reads and locks the version of a product inside the transaction.
+func currentVersion(ctx context.Context, tx *sql.Tx, id string) (int64, error) {
+	var version int64
+	query := fmt.Sprintf("select version from products where id = %s and deletedAt is null for update", q.BuildParam(1))
+	err := tx.QueryRowContext(ctx, query, id).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, &NotFoundError{Resource: productResource, Id: id}
+	}
+	return version, err
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	q "github.com/core-go/sql"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+func NewStockMovementAdapter(db *sql.DB) *StockMovementAdapter {
+	return &StockMovementAdapter{DB: db}
+}
+
+// StockMovementAdapter stores the ledger of the stock movements in stock_movements.
+type StockMovementAdapter struct {
+	DB *sql.DB
+}
+
+// Insert writes the movement in the transaction of the context, together with the change of the stock.
+func (r *StockMovementAdapter) Insert(ctx context.Context, movement *StockMovement) error {
+	query := fmt.Sprintf("insert into stock_movements (productId, storage, delta, balance, reason, reference, actor, movedAt) values (%s, %s, %s, %s, %s, %s, %s, %s)",
+		q.BuildParam(1), q.BuildParam(2), q.BuildParam(3), q.BuildParam(4), q.BuildParam(5), q.BuildParam(6), q.BuildParam(7), q.BuildParam(8))
+	res, err := GetTx(ctx).ExecContext(ctx, query, movement.ProductId, movement.Storage, movement.Delta, movement.Balance, string(movement.Reason), movement.Reference, movement.Actor, movement.MovedAt)
+	if err != nil {
+		return err
+	}
+	movement.Id, err = res.LastInsertId()
+	return err
+}
+
+// List returns the movements of a product, most recent first, and their total.
+func (r *StockMovementAdapter) List(ctx context.Context, productId string, limit int64, offset int64) ([]StockMovement, int64, error) {
+	var total int64
+	queryCount := fmt.Sprintf("select count(*) from stock_movements where productId = %s", q.BuildParam(1))
+	if err := r.DB.QueryRowContext(ctx, queryCount, productId).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	query := fmt.Sprintf("select id, productId, storage, delta, balance, reason, coalesce(reference, ''), actor, movedAt from stock_movements where productId = %s order by movedAt desc, id desc limit %d offset %d", q.BuildParam(1), limit, offset)
+	rows, err := r.DB.QueryContext(ctx, query, productId)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	movements := make([]StockMovement, 0)
+	for rows.Next() {
+		var movement StockMovement
+		var reason string
+		if err = rows.Scan(&movement.Id, &movement.ProductId, &movement.Storage, &movement.Delta, &movement.Balance, &reason, &movement.Reference, &movement.Actor, &movement.MovedAt); err != nil {
+			return nil, 0, err
+		}
+		movement.Reason = MovementReason(reason)
+		movements = append(movements, movement)
+	}
+	return movements, total, rows.Err()
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// executor is implemented by both *sql.DB and *sql.Tx.
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+type txState struct {
+	tx    *sql.Tx
+	depth int
+}
+
+func NewTxManager(db *sql.DB) *TxManager {
+	return &TxManager{DB: db}
+}
+
+// TxManager implements the UnitOfWork port with database/sql transactions.
+type TxManager struct {
+	DB *sql.DB
+}
+
+func (m *TxManager) Do(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return m.doNested(ctx, state, fn)
+	}
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+	if err = fn(context.WithValue(ctx, txKey{}, &txState{tx: tx})); err != nil {
+		if er2 := tx.Rollback(); er2 != nil {
+			return fmt.Errorf("%w (rollback failed: %s)", err, er2.Error())
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *TxManager) doNested(ctx context.Context, state *txState, fn func(ctx context.Context) error) error {
+	state.depth++
+	defer func() { state.depth-- }()
+	savepoint := fmt.Sprintf("sp_%d", state.depth)
+	if _, err := state.tx.ExecContext(ctx, "savepoint "+savepoint); err != nil {
+		return err
+	}
+	if err := fn(ctx); err != nil {
+		if _, er2 := state.tx.ExecContext(ctx, "rollback to savepoint "+savepoint); er2 != nil {
+			return fmt.Errorf("%w (rollback to savepoint failed: %s)", err, er2.Error())
+		}
+		return err
+	}
+	_, err := state.tx.ExecContext(ctx, "release savepoint "+savepoint)
+	return err
+}
+
+// GetTx returns the transaction started by TxManager.Do, or nil outside of a unit of work.
+func GetTx(ctx context.Context) *sql.Tx {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "context"
+
+type actorKey struct{}
+
+// Anonymous is the actor recorded when a request does not identify its user.
+const Anonymous = "anonymous"
+
+// WithActor returns a context which carries the user performing the request.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the user performing the request, or Anonymous.
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && len(actor) > 0 {
+		return actor
+	}
+	return Anonymous
+}
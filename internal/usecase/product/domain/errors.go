@@ -0,0 +1,68 @@
+package domain
+
+import "fmt"
+
+const (
+	CodeNotFound        = "not_found"
+	CodeDuplicateKey    = "duplicate_key"
+	CodeForeignKey      = "foreign_key"
+	CodeValidation      = "validation"
+	CodeVersionMismatch = "version_mismatch"
+	CodeNotDeleted      = "not_deleted"
+	CodeTestFailed      = "test_failed"
+	CodeOutOfStock      = "out_of_stock"
+	CodeNotHeld         = "not_held"
+	CodeTransition      = "transition"
+	CodeConcurrentWrite = "concurrent_write"
+	CodeDeleted         = "deleted"
+)
+
+// NotFoundError is returned when the requested resource does not exist.
+type NotFoundError struct {
+	Resource string
+	Id       string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s '%s' not found", e.Resource, e.Id)
+}
+
+// ConflictError is returned when a write conflicts with existing data, such as a duplicate key
+// or a broken foreign key. Code tells which constraint was violated.
+type ConflictError struct {
+	Resource string
+	Id       string
+	Code     string
+	Message  string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
+
+// ValidationError is returned when a request fails validation; Errors holds one entry per failed rule.
+type ValidationError struct {
+	Errors []ErrorMessage
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Message
+	}
+	return fmt.Sprintf("%d validation errors", len(e.Errors))
+}
+
+// VersionMismatchError is returned when a write is based on a stale version of the resource,
+// or when it requires the resource to exist and it is Missing.
+type VersionMismatchError struct {
+	Resource string
+	Id       string
+	Missing  bool
+}
+
+func (e *VersionMismatchError) Error() string {
+	if e.Missing {
+		return fmt.Sprintf("%s '%s' does not exist", e.Resource, e.Id)
+	}
+	return fmt.Sprintf("%s '%s' has been modified by another request", e.Resource, e.Id)
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord is the response of a request made with an Idempotency-Key, stored to be replayed on retries.
+// StatusCode is 0 while the first request is in progress, which holds the key until LockedUntil, and renews it
+// while it runs; Owner is the token of the request which holds the key.
+type IdempotencyRecord struct {
+	Actor       string            `json:"actor" gorm:"column:actor;primary_key" bson:"actor" dynamodbav:"actor" firestore:"actor" avro:"actor"`
+	Key         string            `json:"key" gorm:"column:idempotencyKey;primary_key" bson:"key" dynamodbav:"key" firestore:"key" avro:"key"`
+	RequestHash string            `json:"requestHash" gorm:"column:requestHash" bson:"requestHash" dynamodbav:"requestHash" firestore:"requestHash" avro:"requestHash"`
+	Owner       string            `json:"owner" gorm:"column:owner" bson:"owner" dynamodbav:"owner" firestore:"owner" avro:"owner"`
+	StatusCode  int               `json:"statusCode" gorm:"column:statusCode" bson:"statusCode" dynamodbav:"statusCode" firestore:"statusCode" avro:"statusCode"`
+	Header      map[string]string `json:"header,omitempty" gorm:"column:header" bson:"header,omitempty" dynamodbav:"header,omitempty" firestore:"header,omitempty" avro:"header"`
+	Body        []byte            `json:"body,omitempty" gorm:"column:body" bson:"body,omitempty" dynamodbav:"body,omitempty" firestore:"body,omitempty" avro:"body"`
+	CreatedAt   time.Time         `json:"createdAt" gorm:"column:createdAt" bson:"createdAt" dynamodbav:"createdAt" firestore:"createdAt" avro:"createdAt"`
+	ExpiresAt   time.Time         `json:"expiresAt" gorm:"column:expiresAt" bson:"expiresAt" dynamodbav:"expiresAt" firestore:"expiresAt" avro:"expiresAt"`
+	LockedUntil time.Time         `json:"lockedUntil" gorm:"column:lockedUntil" bson:"lockedUntil" dynamodbav:"lockedUntil" firestore:"lockedUntil" avro:"lockedUntil"`
+}
+
+// InProgress tells whether the first request of the key has not responded yet.
+func (r *IdempotencyRecord) InProgress() bool {
+	return r.StatusCode == 0
+}
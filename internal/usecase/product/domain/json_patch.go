@@ -0,0 +1,21 @@
+package domain
+
+import "encoding/json"
+
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+	PatchMove    = "move"
+	PatchCopy    = "copy"
+	PatchTest    = "test"
+)
+
+// PatchOperation is an operation of a JSON Patch document (RFC 6902). Path and From are JSON pointers (RFC 6901)
+// into the json of a product, such as "/GeneralInfo/price".
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Amount is a money value expressed in the minor units of its currency (e.g. cents for USD).
+type Amount int64
+
+func (a *Amount) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*a = 0
+	case int64:
+		*a = Amount(v)
+	case []byte:
+		return a.parse(string(v))
+	case string:
+		return a.parse(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Amount", value)
+	}
+	return nil
+}
+
+func (a Amount) Value() (driver.Value, error) {
+	return int64(a), nil
+}
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return errors.New("price must be an integer amount in minor units")
+	}
+	return a.parse(n.String())
+}
+
+func (a *Amount) parse(s string) error {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount %q", s)
+	}
+	*a = Amount(n)
+	return nil
+}
+
+// Currency is an ISO 4217 alphabetic currency code.
+type Currency string
+
+// currencyExponents holds the number of minor unit digits of the supported ISO 4217 currencies.
+var currencyExponents = map[Currency]int{
+	"AUD": 2, "CAD": 2, "CHF": 2, "CNY": 2, "EUR": 2, "GBP": 2, "HKD": 2, "IDR": 2,
+	"INR": 2, "JPY": 0, "KRW": 0, "MYR": 2, "NZD": 2, "PHP": 2, "SGD": 2, "THB": 2,
+	"USD": 2, "VND": 0,
+}
+
+func (c Currency) Valid() bool {
+	_, ok := currencyExponents[c]
+	return ok
+}
+
+// Exponent returns the number of minor unit digits of the currency.
+func (c Currency) Exponent() int {
+	if e, ok := currencyExponents[c]; ok {
+		return e
+	}
+	return 2
+}
+
+func (c *Currency) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*c = ""
+	case []byte:
+		*c = Currency(v)
+	case string:
+		*c = Currency(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Currency", value)
+	}
+	return nil
+}
+
+func (c Currency) Value() (driver.Value, error) {
+	return string(c), nil
+}
+
+func (c *Currency) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*c = Currency(strings.ToUpper(s))
+	return nil
+}
+
+// Money is an amount in minor units together with its ISO 4217 currency.
+type Money struct {
+	Amount   Amount   `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// String formats the money as a decimal followed by its currency, e.g. "10.50 USD".
+func (m Money) String() string {
+	exp := m.Currency.Exponent()
+	v := int64(m.Amount)
+	sign := ""
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	if exp == 0 {
+		return fmt.Sprintf("%s%d %s", sign, v, m.Currency)
+	}
+	p := pow10(exp)
+	return fmt.Sprintf("%s%d.%0*d %s", sign, v/p, exp, v%p, m.Currency)
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+	return p
+}
@@ -1,21 +1,41 @@
 package domain
 
+import "time"
+
 type ProductGeneral struct {
 	Id          string `json:"id" gorm:"column:id;primary_key" bson:"_id" dynamodbav:"id" firestore:"-" avro:"id" validate:"required,max=40" match:"equal"`
 	ProductName string `json:"productName" gorm:"column:productName" bson:"productName" dynamodbav:"productName" firestore:"productName" avro:"productName" validate:"required,productName,max=100" match:"prefix"`
 	Description string `json:"description" gorm:"column:description" bson:"description" dynamodbav:"description" firestore:"description" avro:"description" validate:"description,max=100" match:"prefix"`
-	Price       string `json:"price" gorm:"column:price" bson:"price" dynamodbav:"price" firestore:"price" avro:"price" validate:"required,price,max=18"`
-	Status      string `json:"status" gorm:"column:status" bson:"status" dynamodbav:"status" firestore:"status" avro:"status" validate:"description,max=100" match:"prefix"`
+	Price       Amount `json:"price" gorm:"column:price" bson:"price" dynamodbav:"price" firestore:"price" avro:"price" validate:"required,price"`
+	// BasePrice is the price the product is written with, while a scheduled price is in effect; Price is the one in effect.
+	BasePrice *Amount       `json:"basePrice,omitempty" gorm:"column:basePrice" bson:"basePrice,omitempty" dynamodbav:"basePrice,omitempty" firestore:"basePrice,omitempty" avro:"basePrice"`
+	Currency  Currency      `json:"currency" gorm:"column:currency" bson:"currency" dynamodbav:"currency" firestore:"currency" avro:"currency" validate:"required,currency"`
+	Status    ProductStatus `json:"status" gorm:"column:status" bson:"status" dynamodbav:"status" firestore:"status" avro:"status" validate:"status" match:"equal"`
+	Available bool          `json:"available" gorm:"column:available" bson:"available" dynamodbav:"available" firestore:"available" avro:"available"`
+	Version   int64         `json:"version" gorm:"column:version" bson:"version" dynamodbav:"version" firestore:"version" avro:"version"`
+	DeletedAt *time.Time    `json:"deletedAt,omitempty" gorm:"column:deletedAt" bson:"deletedAt,omitempty" dynamodbav:"deletedAt,omitempty" firestore:"deletedAt,omitempty" avro:"deletedAt"`
+	DeletedBy *string       `json:"deletedBy,omitempty" gorm:"column:deletedBy" bson:"deletedBy,omitempty" dynamodbav:"deletedBy,omitempty" firestore:"deletedBy,omitempty" avro:"deletedBy"`
+}
+
+func (p ProductGeneral) Money() Money {
+	return Money{Amount: p.Price, Currency: p.Currency}
 }
 
+// ProductDetails is the stock of a product in a storage. The DetailInfo of a product summarizes its stocks.
 type ProductDetails struct {
 	ProductID     string `json:"productID" gorm:"column:productID;primary_key" bson:"productID" dynamodbav:"productID" firestore:"productID" avro:"productID"`
-	Supplier      string `json:"supplier" gorm:"column:supplier" bson:"supplier" dynamodbav:"supplier" firestore:"supplier" avro:"supplier"`
-	Storage       string `json:"storage" gorm:"column:storage" bson:"storage" dynamodbav:"storage" firestore:"storage" avro:"storage"`
-	InStockAmount int    `json:"inStockAmount" gorm:"column:inStockAmount" bson:"inStockAmount" dynamodbav:"inStockAmount" firestore:"inStockAmount" avro:"inStockAmount"`
+	Supplier      string `json:"supplier" gorm:"column:supplier" bson:"supplier" dynamodbav:"supplier" firestore:"supplier" avro:"supplier" validate:"max=120"`
+	Storage       string `json:"storage" gorm:"column:storage" bson:"storage" dynamodbav:"storage" firestore:"storage" avro:"storage" validate:"max=45"`
+	InStockAmount int    `json:"inStockAmount" gorm:"column:inStockAmount" bson:"inStockAmount" dynamodbav:"inStockAmount" firestore:"inStockAmount" avro:"inStockAmount" validate:"min=0"`
 }
 
 type Product struct {
-	GeneralInfo ProductGeneral
-	DetailInfo  ProductDetails
+	GeneralInfo  ProductGeneral
+	DetailInfo   ProductDetails
+	Stocks       []ProductDetails     `json:"stocks,omitempty" bson:"stocks,omitempty" dynamodbav:"stocks,omitempty" firestore:"stocks,omitempty" avro:"stocks"`
+	Availability *ProductAvailability `json:"availability,omitempty" bson:"-" dynamodbav:"-" firestore:"-" avro:"-"`
+	Relevance    *float64             `json:"relevance,omitempty" bson:"-" dynamodbav:"-" firestore:"-" avro:"-"`
 }
+
+// AnyVersion is the expected version of a write with If-Match: *, which requires the product to exist, whatever its version.
+const AnyVersion int64 = -1
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditOperation string
+
+const (
+	OperationCreate     AuditOperation = "create"
+	OperationUpdate     AuditOperation = "update"
+	OperationPatch      AuditOperation = "patch"
+	OperationDelete     AuditOperation = "delete"
+	OperationRestore    AuditOperation = "restore"
+	OperationStock      AuditOperation = "stock"
+	OperationTransition AuditOperation = "transition"
+	OperationPrice      AuditOperation = "price"
+)
+
+// ProductAudit records one mutation of a product: who did it, when, and the state before and after.
+type ProductAudit struct {
+	Id        int64                  `json:"id" gorm:"column:id;primary_key" bson:"_id" dynamodbav:"id" firestore:"-" avro:"id"`
+	ProductId string                 `json:"productId" gorm:"column:productId" bson:"productId" dynamodbav:"productId" firestore:"productId" avro:"productId"`
+	Operation AuditOperation         `json:"operation" gorm:"column:operation" bson:"operation" dynamodbav:"operation" firestore:"operation" avro:"operation"`
+	Actor     string                 `json:"actor" gorm:"column:actor" bson:"actor" dynamodbav:"actor" firestore:"actor" avro:"actor"`
+	ChangedAt time.Time              `json:"changedAt" gorm:"column:changedAt" bson:"changedAt" dynamodbav:"changedAt" firestore:"changedAt" avro:"changedAt"`
+	Before    json.RawMessage        `json:"before,omitempty" gorm:"column:beforeState" bson:"before,omitempty" dynamodbav:"before,omitempty" firestore:"before,omitempty" avro:"before"`
+	After     json.RawMessage        `json:"after,omitempty" gorm:"column:afterState" bson:"after,omitempty" dynamodbav:"after,omitempty" firestore:"after,omitempty" avro:"after"`
+	Diff      map[string]FieldChange `json:"diff,omitempty" gorm:"column:diff" bson:"diff,omitempty" dynamodbav:"diff,omitempty" firestore:"diff,omitempty" avro:"diff"`
+}
+
+// FieldChange is the value of a field before and after a mutation.
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
@@ -0,0 +1,49 @@
+package domain
+
+import "time"
+
+type EventType string
+
+const (
+	ProductCreated  EventType = "ProductCreated"
+	ProductUpdated  EventType = "ProductUpdated"
+	ProductPatched  EventType = "ProductPatched"
+	ProductDeleted  EventType = "ProductDeleted"
+	ProductRestored EventType = "ProductRestored"
+	StockChanged    EventType = "StockChanged"
+	StatusChanged   EventType = "StatusChanged"
+	PriceChanged    EventType = "PriceChanged"
+)
+
+// ProductEvent tells downstream services that a product has changed.
+// Product is the state after the change, and is empty when the product has been deleted.
+type ProductEvent struct {
+	Id         string    `json:"id" gorm:"column:id;primary_key" bson:"_id" dynamodbav:"id" firestore:"-" avro:"id"`
+	Type       EventType `json:"type" gorm:"column:eventType" bson:"type" dynamodbav:"type" firestore:"type" avro:"type"`
+	ProductId  string    `json:"productId" gorm:"column:productId" bson:"productId" dynamodbav:"productId" firestore:"productId" avro:"productId"`
+	Actor      string    `json:"actor" gorm:"column:actor" bson:"actor" dynamodbav:"actor" firestore:"actor" avro:"actor"`
+	OccurredAt time.Time `json:"occurredAt" gorm:"column:occurredAt" bson:"occurredAt" dynamodbav:"occurredAt" firestore:"occurredAt" avro:"occurredAt"`
+	Product    *Product  `json:"product,omitempty" gorm:"column:payload" bson:"product,omitempty" dynamodbav:"product,omitempty" firestore:"product,omitempty" avro:"product"`
+}
+
+// EventTypeOf returns the event which is emitted by an audited operation.
+func EventTypeOf(operation AuditOperation) EventType {
+	switch operation {
+	case OperationCreate:
+		return ProductCreated
+	case OperationPatch:
+		return ProductPatched
+	case OperationDelete:
+		return ProductDeleted
+	case OperationRestore:
+		return ProductRestored
+	case OperationStock:
+		return StockChanged
+	case OperationTransition:
+		return StatusChanged
+	case OperationPrice:
+		return PriceChanged
+	default:
+		return ProductUpdated
+	}
+}
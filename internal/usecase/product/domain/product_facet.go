@@ -0,0 +1,19 @@
+package domain
+
+// FacetFields are the fields which a search can count its products by.
+var FacetFields = []string{"status", "supplier", "storage", "currency"}
+
+func IsFacetField(field string) bool {
+	for _, f := range FacetFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// FacetCount is the number of matching products which have a value of a facet field.
+type FacetCount struct {
+	Value string `json:"value" bson:"value" dynamodbav:"value" firestore:"value" avro:"value"`
+	Count int64  `json:"count" bson:"count" dynamodbav:"count" firestore:"count" avro:"count"`
+}
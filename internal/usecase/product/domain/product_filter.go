@@ -2,11 +2,48 @@ package domain
 
 import "github.com/core-go/search"
 
+// TextMatch is how the text criteria of a filter (productName, description) are compared; comparisons ignore case.
+type TextMatch string
+
+const (
+	MatchPrefix   TextMatch = "prefix"
+	MatchContains TextMatch = "contains"
+	MatchEqual    TextMatch = "equal"
+)
+
+func (m TextMatch) Valid() bool {
+	return m == MatchPrefix || m == MatchContains || m == MatchEqual
+}
+
 type ProductFilter struct {
 	*search.Filter
-	Id          string `json:"id" gorm:"column:id;primary_key" bson:"_id" dynamodbav:"id" firestore:"id" avro:"id" validate:"required,max=40" match:"equal"`
-	productName string `json:"productName" gorm:"column:productName" bson:"productName" dynamodbav:"productName" firestore:"productName" avro:"productName" validate:"required,productName,max=100" match:"prefix" q:"prefix"`
-	description string `json:"description" gorm:"column:description" bson:"description" dynamodbav:"description" firestore:"description" avro:"description" validate:"description,max=100" match:"prefix" q:"prefix"`
-	price       string `json:"price" gorm:"column:price" bson:"price" dynamodbav:"price" firestore:"price" avro:"price" validate:"required,price,max=18" q:"true"`
-	status      string `json:"status" gorm:"column:status" bson:"status" dynamodbav:"status" firestore:"status" avro:"status"`
+	Id             string              `json:"id" gorm:"column:id;primary_key" bson:"_id" dynamodbav:"id" firestore:"id" avro:"id" match:"equal"`
+	ProductName    string              `json:"productName" gorm:"column:productName" bson:"productName" dynamodbav:"productName" firestore:"productName" avro:"productName" match:"prefix"`
+	Description    string              `json:"description" gorm:"column:description" bson:"description" dynamodbav:"description" firestore:"description" avro:"description" match:"prefix"`
+	Match          TextMatch           `json:"match,omitempty" bson:"match,omitempty" dynamodbav:"match,omitempty" firestore:"match,omitempty" avro:"match"`
+	Price          *search.NumberRange `json:"price" gorm:"column:price" bson:"price" dynamodbav:"price" firestore:"price" avro:"price"`
+	Status         []ProductStatus     `json:"status" gorm:"column:status" bson:"status" dynamodbav:"status" firestore:"status" avro:"status" match:"in"`
+	Supplier       string              `json:"supplier" gorm:"column:supplier" bson:"supplier" dynamodbav:"supplier" firestore:"supplier" avro:"supplier" match:"equal"`
+	Storage        string              `json:"storage" gorm:"column:storage" bson:"storage" dynamodbav:"storage" firestore:"storage" avro:"storage" match:"equal"`
+	InStockAmount  *search.NumberRange `json:"inStockAmount" gorm:"column:inStockAmount" bson:"inStockAmount" dynamodbav:"inStockAmount" firestore:"inStockAmount" avro:"inStockAmount"`
+	Available      *bool               `json:"available,omitempty" gorm:"column:available" bson:"available,omitempty" dynamodbav:"available,omitempty" firestore:"available,omitempty" avro:"available"`
+	IncludeDeleted bool                `json:"includeDeleted" bson:"includeDeleted" dynamodbav:"includeDeleted" firestore:"includeDeleted" avro:"includeDeleted"`
+	Include        []string            `json:"include,omitempty" bson:"include,omitempty" dynamodbav:"include,omitempty" firestore:"include,omitempty" avro:"include"`
+	Facets         []string            `json:"facets,omitempty" bson:"facets,omitempty" dynamodbav:"facets,omitempty" firestore:"facets,omitempty" avro:"facets"`
+	Cursor         bool                `json:"cursor,omitempty" bson:"cursor,omitempty" dynamodbav:"cursor,omitempty" firestore:"cursor,omitempty" avro:"cursor"`
+	NextPageToken  string              `json:"nextPageToken,omitempty" bson:"nextPageToken,omitempty" dynamodbav:"nextPageToken,omitempty" firestore:"nextPageToken,omitempty" avro:"nextPageToken"`
+}
+
+// CursorPaging tells whether pages are read after a page token instead of by page index: when the filter has a token,
+// or asks for the first page with cursor.
+func (f *ProductFilter) CursorPaging() bool {
+	return f.Cursor || len(f.NextPageToken) > 0
+}
+
+// Projection is what the search reads of the products, from the fields of the filter and its include.
+func (f *ProductFilter) Projection() *ProductProjection {
+	if f.Filter == nil {
+		return NewProductProjection(nil, f.Include)
+	}
+	return NewProductProjection(f.Fields, f.Include)
 }
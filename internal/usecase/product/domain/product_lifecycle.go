@@ -0,0 +1,40 @@
+package domain
+
+// Lifecycle is the state machine of the statuses of products: the status of new products,
+// and the statuses each status can move to.
+type Lifecycle struct {
+	Initial     ProductStatus
+	Transitions map[ProductStatus][]ProductStatus
+}
+
+// DefaultLifecycle starts products as drafts; archived products cannot move any more.
+func DefaultLifecycle() *Lifecycle {
+	return &Lifecycle{
+		Initial: StatusDraft,
+		Transitions: map[ProductStatus][]ProductStatus{
+			StatusDraft:        {StatusActive, StatusArchived},
+			StatusActive:       {StatusDiscontinued},
+			StatusDiscontinued: {StatusActive, StatusArchived},
+		},
+	}
+}
+
+// Allows tells whether a product can move from a status to another.
+func (l *Lifecycle) Allows(from ProductStatus, to ProductStatus) bool {
+	for _, status := range l.Transitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsNew tells whether a product can be created with a status: the initial one, or one it can move to.
+func (l *Lifecycle) AllowsNew(status ProductStatus) bool {
+	return status == l.Initial || l.Allows(l.Initial, status)
+}
+
+// ProductTransition moves a product to another status.
+type ProductTransition struct {
+	To ProductStatus `json:"to" bson:"to" dynamodbav:"to" firestore:"to" avro:"to" validate:"required,status"`
+}
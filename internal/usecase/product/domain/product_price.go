@@ -0,0 +1,42 @@
+package domain
+
+import "time"
+
+// ScheduledPrice is a price of a product, in its currency, which is in effect from EffectiveFrom until EffectiveTo,
+// or with no end when EffectiveTo is nil. When several scheduled prices are in effect, the one with the highest
+// priority wins, then the one which started last.
+type ScheduledPrice struct {
+	Id            string     `json:"id" gorm:"column:id;primary_key" bson:"_id" dynamodbav:"id" firestore:"-" avro:"id"`
+	ProductId     string     `json:"productId" gorm:"column:productId" bson:"productId" dynamodbav:"productId" firestore:"productId" avro:"productId"`
+	Price         Amount     `json:"price" gorm:"column:price" bson:"price" dynamodbav:"price" firestore:"price" avro:"price" validate:"required,price"`
+	EffectiveFrom time.Time  `json:"effectiveFrom" gorm:"column:effectiveFrom" bson:"effectiveFrom" dynamodbav:"effectiveFrom" firestore:"effectiveFrom" avro:"effectiveFrom" validate:"required"`
+	EffectiveTo   *time.Time `json:"effectiveTo,omitempty" gorm:"column:effectiveTo" bson:"effectiveTo,omitempty" dynamodbav:"effectiveTo,omitempty" firestore:"effectiveTo,omitempty" avro:"effectiveTo"`
+	Priority      int        `json:"priority" gorm:"column:priority" bson:"priority" dynamodbav:"priority" firestore:"priority" avro:"priority"`
+	Actor         string     `json:"actor" gorm:"column:actor" bson:"actor" dynamodbav:"actor" firestore:"actor" avro:"actor"`
+	CreatedAt     time.Time  `json:"createdAt" gorm:"column:createdAt" bson:"createdAt" dynamodbav:"createdAt" firestore:"createdAt" avro:"createdAt"`
+}
+
+// PriceChange is an entry of the price history: the price in effect for a product from ChangedAt.
+// ScheduleId is the scheduled price which came into effect, and is empty when the product was written with the price.
+type PriceChange struct {
+	Id            int64     `json:"id" gorm:"column:id;primary_key" bson:"_id" dynamodbav:"id" firestore:"-" avro:"id"`
+	ProductId     string    `json:"productId" gorm:"column:productId" bson:"productId" dynamodbav:"productId" firestore:"productId" avro:"productId"`
+	Price         Amount    `json:"price" gorm:"column:price" bson:"price" dynamodbav:"price" firestore:"price" avro:"price"`
+	PreviousPrice *Amount   `json:"previousPrice,omitempty" gorm:"column:previousPrice" bson:"previousPrice,omitempty" dynamodbav:"previousPrice,omitempty" firestore:"previousPrice,omitempty" avro:"previousPrice"`
+	ScheduleId    string    `json:"scheduleId,omitempty" gorm:"column:scheduleId" bson:"scheduleId,omitempty" dynamodbav:"scheduleId,omitempty" firestore:"scheduleId,omitempty" avro:"scheduleId"`
+	Actor         string    `json:"actor" gorm:"column:actor" bson:"actor" dynamodbav:"actor" firestore:"actor" avro:"actor"`
+	ChangedAt     time.Time `json:"changedAt" gorm:"column:changedAt" bson:"changedAt" dynamodbav:"changedAt" firestore:"changedAt" avro:"changedAt"`
+}
+
+// PendingPrice is a product whose stored price is not the one in effect: Price and BasePrice are stored,
+// and ScheduledPrice is the price of the schedule in effect, or nil when none is. Attempts counts the runs which
+// failed to store its price.
+type PendingPrice struct {
+	ProductId      string
+	Version        int64
+	Price          Amount
+	BasePrice      *Amount
+	ScheduleId     string
+	ScheduledPrice *Amount
+	Attempts       int
+}
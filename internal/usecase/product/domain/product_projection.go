@@ -0,0 +1,25 @@
+package domain
+
+// IncludeDetails is the value of "include" which reads the DetailInfo of products.
+const IncludeDetails = "details"
+
+// ProductProjection selects what is read of products: the fields of ProductGeneral named by their json names
+// (all of them when Fields is empty), and DetailInfo when Details is set. A nil projection reads the whole product.
+type ProductProjection struct {
+	Fields  []string
+	Details bool
+}
+
+// NewProductProjection returns the projection of the "fields" and "include" parameters, or nil when both are empty.
+func NewProductProjection(fields []string, include []string) *ProductProjection {
+	if len(fields) == 0 && len(include) == 0 {
+		return nil
+	}
+	projection := &ProductProjection{Fields: fields}
+	for _, s := range include {
+		if s == IncludeDetails {
+			projection.Details = true
+		}
+	}
+	return projection
+}
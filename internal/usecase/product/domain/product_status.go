@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+type ProductStatus string
+
+// ProductStatus is the status of a product in its Lifecycle; whether it is in stock is told by Available.
+const (
+	StatusDraft        ProductStatus = "draft"
+	StatusActive       ProductStatus = "active"
+	StatusDiscontinued ProductStatus = "discontinued"
+	StatusArchived     ProductStatus = "archived"
+)
+
+func (s ProductStatus) Valid() bool {
+	return s == StatusDraft || s == StatusActive || s == StatusDiscontinued || s == StatusArchived
+}
+
+func (s *ProductStatus) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*s = ""
+	case []byte:
+		*s = ProductStatus(v)
+	case string:
+		*s = ProductStatus(v)
+	default:
+		return fmt.Errorf("cannot scan %T into ProductStatus", value)
+	}
+	return nil
+}
+
+func (s ProductStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+func (s *ProductStatus) UnmarshalJSON(data []byte) error {
+	var v string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	status := ProductStatus(v)
+	if len(v) > 0 && !status.Valid() {
+		return fmt.Errorf("invalid product status %q", v)
+	}
+	*s = status
+	return nil
+}
@@ -0,0 +1,51 @@
+package domain
+
+// IsEmpty tells whether details have no supplier, no storage and no stock.
+func (d ProductDetails) IsEmpty() bool {
+	return len(d.Supplier) == 0 && len(d.Storage) == 0 && d.InStockAmount == 0
+}
+
+// TotalStock is the stock of a product across its storages.
+func TotalStock(stocks []ProductDetails) int {
+	total := 0
+	for _, stock := range stocks {
+		total += stock.InStockAmount
+	}
+	return total
+}
+
+// SetStock returns a copy of stocks where stock replaces the stock of the same storage, or is added.
+func SetStock(stocks []ProductDetails, stock ProductDetails) []ProductDetails {
+	res := make([]ProductDetails, 0, len(stocks)+1)
+	found := false
+	for _, s := range stocks {
+		if s.Storage == stock.Storage {
+			s, found = stock, true
+		}
+		res = append(res, s)
+	}
+	if !found {
+		res = append(res, stock)
+	}
+	return res
+}
+
+// SummarizeStocks returns the DetailInfo of a product from its stocks: the total stock, the storage when there is
+// only one, and the supplier when all the stocks have the same one.
+func SummarizeStocks(productID string, stocks []ProductDetails) ProductDetails {
+	details := ProductDetails{ProductID: productID, InStockAmount: TotalStock(stocks)}
+	if len(stocks) == 0 {
+		return details
+	}
+	details.Supplier = stocks[0].Supplier
+	for _, stock := range stocks[1:] {
+		if stock.Supplier != details.Supplier {
+			details.Supplier = ""
+			break
+		}
+	}
+	if len(stocks) == 1 {
+		details.Storage = stocks[0].Storage
+	}
+	return details
+}
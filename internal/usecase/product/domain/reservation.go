@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+type ReservationStatus string
+
+const (
+	ReservationHeld      ReservationStatus = "held"
+	ReservationConfirmed ReservationStatus = "confirmed"
+	ReservationReleased  ReservationStatus = "released"
+	ReservationExpired   ReservationStatus = "expired"
+)
+
+// Reservation holds a quantity of a product in a storage, taken from its stock while it is held.
+// A held reservation expires at ExpiresAt unless it is confirmed; a released or expired reservation gives its quantity back.
+type Reservation struct {
+	Id        string            `json:"id" gorm:"column:id;primary_key" bson:"_id" dynamodbav:"id" firestore:"-" avro:"id"`
+	ProductId string            `json:"productId" gorm:"column:productId" bson:"productId" dynamodbav:"productId" firestore:"productId" avro:"productId"`
+	Storage   string            `json:"storage" gorm:"column:storage" bson:"storage" dynamodbav:"storage" firestore:"storage" avro:"storage" validate:"max=45"`
+	Quantity  int               `json:"quantity" gorm:"column:quantity" bson:"quantity" dynamodbav:"quantity" firestore:"quantity" avro:"quantity" validate:"required,min=1"`
+	Status    ReservationStatus `json:"status" gorm:"column:status" bson:"status" dynamodbav:"status" firestore:"status" avro:"status"`
+	Actor     string            `json:"actor" gorm:"column:actor" bson:"actor" dynamodbav:"actor" firestore:"actor" avro:"actor"`
+	CreatedAt time.Time         `json:"createdAt" gorm:"column:createdAt" bson:"createdAt" dynamodbav:"createdAt" firestore:"createdAt" avro:"createdAt"`
+	ExpiresAt time.Time         `json:"expiresAt" gorm:"column:expiresAt" bson:"expiresAt" dynamodbav:"expiresAt" firestore:"expiresAt" avro:"expiresAt"`
+	UpdatedAt *time.Time        `json:"updatedAt,omitempty" gorm:"column:updatedAt" bson:"updatedAt,omitempty" dynamodbav:"updatedAt,omitempty" firestore:"updatedAt,omitempty" avro:"updatedAt"`
+}
+
+// ProductAvailability tells how much of the stock of a product can be reserved, and how much is held by reservations.
+type ProductAvailability struct {
+	Available int `json:"available" bson:"available" dynamodbav:"available" firestore:"available" avro:"available"`
+	Reserved  int `json:"reserved" bson:"reserved" dynamodbav:"reserved" firestore:"reserved" avro:"reserved"`
+}
@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+type MovementReason string
+
+const (
+	MovementReceipt     MovementReason = "receipt"
+	MovementShipment    MovementReason = "shipment"
+	MovementReturn      MovementReason = "return"
+	MovementDamage      MovementReason = "damage"
+	MovementCount       MovementReason = "count"
+	MovementReservation MovementReason = "reservation"
+	MovementRelease     MovementReason = "release"
+)
+
+// Valid tells whether a reason can be given to a movement by a client; the reservations record their own movements.
+func (r MovementReason) Valid() bool {
+	switch r {
+	case MovementReceipt, MovementShipment, MovementReturn, MovementDamage, MovementCount:
+		return true
+	}
+	return false
+}
+
+// StockMovement is a change of the stock of a product in a storage. Balance is the stock of the storage after it.
+type StockMovement struct {
+	Id        int64          `json:"id" gorm:"column:id;primary_key" bson:"_id" dynamodbav:"id" firestore:"-" avro:"id"`
+	ProductId string         `json:"productId" gorm:"column:productId" bson:"productId" dynamodbav:"productId" firestore:"productId" avro:"productId"`
+	Storage   string         `json:"storage" gorm:"column:storage" bson:"storage" dynamodbav:"storage" firestore:"storage" avro:"storage" validate:"max=45"`
+	Delta     int            `json:"delta" gorm:"column:delta" bson:"delta" dynamodbav:"delta" firestore:"delta" avro:"delta" validate:"required"`
+	Balance   int            `json:"balance" gorm:"column:balance" bson:"balance" dynamodbav:"balance" firestore:"balance" avro:"balance"`
+	Reason    MovementReason `json:"reason" gorm:"column:reason" bson:"reason" dynamodbav:"reason" firestore:"reason" avro:"reason" validate:"required,reason"`
+	Reference string         `json:"reference,omitempty" gorm:"column:reference" bson:"reference,omitempty" dynamodbav:"reference,omitempty" firestore:"reference,omitempty" avro:"reference" validate:"max=120"`
+	Actor     string         `json:"actor" gorm:"column:actor" bson:"actor" dynamodbav:"actor" firestore:"actor" avro:"actor"`
+	MovedAt   time.Time      `json:"movedAt" gorm:"column:movedAt" bson:"movedAt" dynamodbav:"movedAt" firestore:"movedAt" avro:"movedAt"`
+}
@@ -0,0 +1,10 @@
+package port
+
+import (
+	"context"
+	. "go-service/internal/usecase/product/domain"
+)
+
+type EventPublisher interface {
+	Publish(ctx context.Context, event ProductEvent) error
+}
@@ -0,0 +1,23 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+type IdempotencyRepository interface {
+	// Begin stores a record in progress for its actor and key, locked until its LockedUntil. If there is already a record
+	// which has not expired, it stores nothing and returns that record, unless it is a record of the same request which is
+	// still in progress after its lock, because its owner stopped: the record is then taken over by the owner of record,
+	// locked again, and nil is returned.
+	Begin(ctx context.Context, record *IdempotencyRecord) (*IdempotencyRecord, error)
+	// Renew extends the lock of a record in progress to its LockedUntil, if the record still has its owner.
+	Renew(ctx context.Context, record *IdempotencyRecord) error
+	// Complete stores the response of a record; it fails when the record does not have its owner any more.
+	Complete(ctx context.Context, record *IdempotencyRecord) error
+	// Remove removes a record, if it still has its owner.
+	Remove(ctx context.Context, record *IdempotencyRecord) error
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
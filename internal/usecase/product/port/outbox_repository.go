@@ -0,0 +1,15 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+type OutboxRepository interface {
+	Insert(ctx context.Context, event *ProductEvent) error
+	Pending(ctx context.Context, limit int) ([]ProductEvent, error)
+	MarkPublished(ctx context.Context, id string) error
+	MarkFailed(ctx context.Context, id string, reason string, retryAt time.Time) error
+}
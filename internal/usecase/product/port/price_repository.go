@@ -0,0 +1,22 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+type PriceRepository interface {
+	Insert(ctx context.Context, price *ScheduledPrice) error
+	List(ctx context.Context, productId string, limit int64, offset int64) ([]ScheduledPrice, int64, error)
+	// Pending returns up to limit products whose stored price is not the one in effect at now.
+	Pending(ctx context.Context, now time.Time, limit int) ([]PendingPrice, error)
+	// MarkFailed records a failure to store the price of a product, which is not pending again before retryAt.
+	MarkFailed(ctx context.Context, productId string, reason string, retryAt time.Time) error
+	ClearFailure(ctx context.Context, productId string) error
+	InsertChange(ctx context.Context, change *PriceChange) error
+	// LatestChange returns the last entry of the price history of a product, or nil when it has none.
+	LatestChange(ctx context.Context, productId string) (*PriceChange, error)
+	History(ctx context.Context, productId string, limit int64, offset int64) ([]PriceChange, int64, error)
+}
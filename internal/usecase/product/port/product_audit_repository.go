@@ -0,0 +1,11 @@
+package port
+
+import (
+	"context"
+	. "go-service/internal/usecase/product/domain"
+)
+
+type ProductAuditRepository interface {
+	Insert(ctx context.Context, audit *ProductAudit) error
+	History(ctx context.Context, productId string, limit int64, offset int64) ([]ProductAudit, int64, error)
+}
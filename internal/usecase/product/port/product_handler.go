@@ -9,4 +9,22 @@ type ProductHandler interface {
 	Update(w http.ResponseWriter, r *http.Request)
 	Patch(w http.ResponseWriter, r *http.Request)
 	Delete(w http.ResponseWriter, r *http.Request)
+	Restore(w http.ResponseWriter, r *http.Request)
+	History(w http.ResponseWriter, r *http.Request)
+	CreateBatch(w http.ResponseWriter, r *http.Request)
+	UpsertBatch(w http.ResponseWriter, r *http.Request)
+	DeleteBatch(w http.ResponseWriter, r *http.Request)
+	Export(w http.ResponseWriter, r *http.Request)
+	Import(w http.ResponseWriter, r *http.Request)
+	Stocks(w http.ResponseWriter, r *http.Request)
+	SetStock(w http.ResponseWriter, r *http.Request)
+	MoveStock(w http.ResponseWriter, r *http.Request)
+	StockMovements(w http.ResponseWriter, r *http.Request)
+	Reserve(w http.ResponseWriter, r *http.Request)
+	ConfirmReservation(w http.ResponseWriter, r *http.Request)
+	ReleaseReservation(w http.ResponseWriter, r *http.Request)
+	Transition(w http.ResponseWriter, r *http.Request)
+	SchedulePrice(w http.ResponseWriter, r *http.Request)
+	ScheduledPrices(w http.ResponseWriter, r *http.Request)
+	PriceHistory(w http.ResponseWriter, r *http.Request)
 }
@@ -2,13 +2,41 @@ package port
 
 import (
 	"context"
+	"time"
+
 	. "go-service/internal/usecase/product/domain"
 )
 
 type ProductRepository interface {
 	Load(ctx context.Context, id string) (*Product, error)
+	// LoadForUpdate locks a product in the transaction of ctx and loads it; a deleted product is locked, and is a
+	// ConflictError with the code CodeDeleted.
+	LoadForUpdate(ctx context.Context, id string) (*Product, error)
+	LoadProjection(ctx context.Context, id string, projection *ProductProjection) (*Product, error)
 	Create(ctx context.Context, product *Product) (int64, error)
 	Update(ctx context.Context, product *Product) (int64, error)
-	Patch(ctx context.Context, product map[string]interface{}) (int64, error)
-	Delete(ctx context.Context, id string) (int64, error)
+	Upsert(ctx context.Context, product *Product) (int64, error)
+	Delete(ctx context.Context, id string, version int64) (int64, error)
+	Restore(ctx context.Context, id string, version int64) (int64, error)
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	LoadMany(ctx context.Context, ids []string) (map[string]*Product, error)
+	// LoadManyForUpdate locks products in the transaction of ctx and loads them, as LoadForUpdate; the ids of the
+	// deleted products are returned apart.
+	LoadManyForUpdate(ctx context.Context, ids []string) (map[string]*Product, map[string]bool, error)
+	CreateBatch(ctx context.Context, products []Product) (int64, error)
+	UpsertBatch(ctx context.Context, products []Product) (int64, error)
+	DeleteBatch(ctx context.Context, ids []string) (int64, error)
+	Export(ctx context.Context, filter *ProductFilter, write func(product *Product) error) error
+	// AdjustStock adds delta to the stock of a product in a storage, unless the stock would become negative;
+	// it returns 0 when it is not adjusted.
+	AdjustStock(ctx context.Context, id string, storage string, delta int) (int64, error)
+	// LoadStocks loads the stocks of a product, deleted or not, ordered by storage.
+	LoadStocks(ctx context.Context, id string) ([]ProductDetails, error)
+	// UpdateStatus sets the status of a product which has the given version, and increments its version.
+	UpdateStatus(ctx context.Context, id string, version int64, status ProductStatus) (int64, error)
+	// UpdateAvailability sets whether a product is in stock, and increments its version.
+	UpdateAvailability(ctx context.Context, id string, available bool) (int64, error)
+	// UpdatePrice stores the price in effect of a product which has the given version, and its base price, and increments
+	// its version, so that a write based on the price before fails. It returns 0 when it is not updated.
+	UpdatePrice(ctx context.Context, id string, version int64, price Amount, basePrice *Amount) (int64, error)
 }
@@ -0,0 +1,19 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+type ReservationRepository interface {
+	Insert(ctx context.Context, reservation *Reservation) error
+	Load(ctx context.Context, id string) (*Reservation, error)
+	// UpdateStatus changes the status of a reservation only if it has the status from, and returns the affected rows.
+	UpdateStatus(ctx context.Context, id string, from ReservationStatus, to ReservationStatus) (int64, error)
+	// Reserved returns the quantity of a product held by reservations.
+	Reserved(ctx context.Context, productId string) (int, error)
+	// Expired returns the held reservations which expire before a time, the earliest first.
+	Expired(ctx context.Context, before time.Time, limit int) ([]Reservation, error)
+}
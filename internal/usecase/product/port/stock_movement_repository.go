@@ -0,0 +1,12 @@
+package port
+
+import (
+	"context"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+type StockMovementRepository interface {
+	Insert(ctx context.Context, movement *StockMovement) error
+	List(ctx context.Context, productId string, limit int64, offset int64) ([]StockMovement, int64, error)
+}
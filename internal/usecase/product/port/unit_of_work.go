@@ -0,0 +1,10 @@
+package port
+
+import "context"
+
+// UnitOfWork runs a function in a transaction, which the repositories find in the context given to the function.
+// The transaction is committed if the function returns nil, and rolled back otherwise.
+// A nested call joins the transaction of its context and only rolls back its own work, using a savepoint.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
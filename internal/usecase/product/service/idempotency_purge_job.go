@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "go-service/internal/usecase/product/port"
+)
+
+// IdempotencyPurgeJob periodically removes the expired responses of idempotent requests.
+type IdempotencyPurgeJob struct {
+	repository IdempotencyRepository
+	interval   time.Duration
+	logError   func(context.Context, string)
+}
+
+func NewIdempotencyPurgeJob(repository IdempotencyRepository, interval time.Duration, logError func(context.Context, string)) *IdempotencyPurgeJob {
+	return &IdempotencyPurgeJob{repository: repository, interval: interval, logError: logError}
+}
+
+// Run purges once per interval until ctx is cancelled.
+func (j *IdempotencyPurgeJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := j.repository.Purge(ctx, time.Now()); err != nil && j.logError != nil {
+				j.logError(ctx, fmt.Sprintf("cannot purge idempotency keys: %s", err.Error()))
+			}
+		}
+	}
+}
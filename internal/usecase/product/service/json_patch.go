@@ -0,0 +1,179 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// patchableFields are the fields of GeneralInfo and DetailInfo which a JSON Patch may change; the others may only be tested.
+var patchableFields = map[string]map[string]bool{
+	generalInfo: {"productName": true, "description": true, "price": true, "currency": true},
+	detailInfo:  {"supplier": true, "storage": true, "inStockAmount": true},
+}
+
+// readOnlyFields may be tested, but not changed, by a JSON Patch.
+var readOnlyFields = map[string]map[string]bool{
+	generalInfo: {"id": true, "basePrice": true, "status": true, "available": true, "version": true, "deletedAt": true, "deletedBy": true},
+	detailInfo:  {"productID": true},
+}
+
+// ApplyJSONPatch applies the operations of a JSON Patch (RFC 6902) to the json of a product, in order.
+// A path which is not a field of the product, or a change of a read-only field, is a validation error,
+// and a failed "test" is a conflict, so that nothing is changed.
+func ApplyJSONPatch(product *Product, operations []PatchOperation) error {
+	document, err := productDocument(product)
+	if err != nil {
+		return err
+	}
+	for i, operation := range operations {
+		if err = applyOperation(document, i, operation); err != nil {
+			return err
+		}
+	}
+	return toProduct(document, product)
+}
+
+func applyOperation(doc map[string]interface{}, i int, operation PatchOperation) error {
+	path, err := checkPatchPath(i, "path", operation.Path, operation.Op == PatchTest)
+	if err != nil {
+		return err
+	}
+	var value interface{}
+	switch operation.Op {
+	case PatchAdd, PatchReplace, PatchTest:
+		if len(operation.Value) == 0 {
+			return patchError(i, "value", "required", fmt.Sprintf("operation %d (%s) must have a value", i, operation.Op))
+		}
+		if value, err = decodeValue(operation.Value); err != nil {
+			return patchError(i, "value", "type", fmt.Sprintf("operation %d (%s) has an invalid value: %s", i, operation.Op, err.Error()))
+		}
+	case PatchMove, PatchCopy:
+		from, err := checkPatchPath(i, "from", operation.From, operation.Op == PatchCopy)
+		if err != nil {
+			return err
+		}
+		if value, err = getValue(doc, from); err != nil {
+			return patchError(i, "from", "path", fmt.Sprintf("operation %d (%s): %s", i, operation.Op, err.Error()))
+		}
+		if operation.Op == PatchMove {
+			if err = removeValue(doc, from); err != nil {
+				return patchError(i, "from", "path", fmt.Sprintf("operation %d (%s): %s", i, operation.Op, err.Error()))
+			}
+		}
+	case PatchRemove:
+	default:
+		return patchError(i, "op", "op", fmt.Sprintf("operation %d has an unknown op %q", i, operation.Op))
+	}
+
+	switch operation.Op {
+	case PatchTest:
+		current, err := getValue(doc, path)
+		if err != nil || !equalValues(current, value) {
+			return &ConflictError{Resource: "product", Code: CodeTestFailed, Message: fmt.Sprintf("operation %d (test) failed: %s is not %s", i, operation.Path, string(operation.Value))}
+		}
+	case PatchRemove:
+		err = removeValue(doc, path)
+	case PatchReplace:
+		if _, err = getValue(doc, path); err == nil {
+			setValue(doc, path, value)
+		}
+	default:
+		setValue(doc, path, value)
+	}
+	if err != nil {
+		return patchError(i, "path", "path", fmt.Sprintf("operation %d (%s): %s", i, operation.Op, err.Error()))
+	}
+	return nil
+}
+
+// checkPatchPath parses a JSON pointer, which must be a field of GeneralInfo or DetailInfo; read-only fields are allowed when readOnly is set.
+func checkPatchPath(i int, name string, pointer string, readOnly bool) ([]string, error) {
+	path, err := parsePointer(pointer)
+	if err != nil {
+		return nil, patchError(i, name, "path", fmt.Sprintf("operation %d: %s", i, err.Error()))
+	}
+	if len(path) == 2 {
+		if patchableFields[path[0]][path[1]] {
+			return path, nil
+		}
+		if readOnlyFields[path[0]][path[1]] {
+			if readOnly {
+				return path, nil
+			}
+			return nil, patchError(i, name, "read_only", fmt.Sprintf("operation %d: %s is read-only", i, pointer))
+		}
+	}
+	return nil, patchError(i, name, "path", fmt.Sprintf("operation %d: %q is not a field of product", i, pointer))
+}
+
+func patchError(i int, name string, code string, message string) error {
+	return &ValidationError{Errors: []ErrorMessage{{Field: fmt.Sprintf("[%d].%s", i, name), Code: code, Message: message}}}
+}
+
+// parsePointer splits a JSON pointer into its unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if len(pointer) == 0 || pointer[0] != '/' {
+		return nil, fmt.Errorf("%q is not a json pointer", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func decodeValue(raw json.RawMessage) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value interface{}
+	err := decoder.Decode(&value)
+	return value, err
+}
+
+// getValue returns the value of a field; path is a field of GeneralInfo or DetailInfo, as checked by checkPatchPath.
+func getValue(doc map[string]interface{}, path []string) (interface{}, error) {
+	part, ok := doc[path[0]].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s does not exist", path[0])
+	}
+	value, ok := part[path[1]]
+	if !ok {
+		return nil, fmt.Errorf("%s/%s does not exist", path[0], path[1])
+	}
+	return value, nil
+}
+
+// equalValues tells whether a value is the tested one. Numbers are equal by value, as 100000 and 1e5 or 2.50 and 2.5.
+func equalValues(current interface{}, value interface{}) bool {
+	x, ok1 := current.(json.Number)
+	y, ok2 := value.(json.Number)
+	if !ok1 || !ok2 {
+		return reflect.DeepEqual(current, value)
+	}
+	a, ok1 := new(big.Float).SetString(x.String())
+	b, ok2 := new(big.Float).SetString(y.String())
+	return ok1 && ok2 && a.Cmp(b) == 0
+}
+
+func setValue(doc map[string]interface{}, path []string, value interface{}) {
+	part, ok := doc[path[0]].(map[string]interface{})
+	if !ok {
+		part = make(map[string]interface{})
+		doc[path[0]] = part
+	}
+	part[path[1]] = value
+}
+
+func removeValue(doc map[string]interface{}, path []string) error {
+	if _, err := getValue(doc, path); err != nil {
+		return err
+	}
+	delete(doc[path[0]].(map[string]interface{}), path[1])
+	return nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+func patchedProduct() *Product {
+	return &Product{
+		GeneralInfo: ProductGeneral{Id: "P001", ProductName: "Iron Man", Description: "toys", Price: 100000, Currency: "USD", Status: StatusActive, Available: true, Version: 3},
+		DetailInfo:  ProductDetails{ProductID: "P001", Supplier: "LEGO inc.", Storage: "north", InStockAmount: 1000},
+	}
+}
+
+func op(op string, path string, value string) PatchOperation {
+	operation := PatchOperation{Op: op, Path: path}
+	if len(value) > 0 {
+		operation.Value = json.RawMessage(value)
+	}
+	return operation
+}
+
+func from(op string, from string, path string) PatchOperation {
+	return PatchOperation{Op: op, From: from, Path: path}
+}
+
+// checkPatchError checks that err is a conflict with code, or a validation error of field with code; an empty code
+// expects no error.
+func checkPatchError(t *testing.T, err error, conflict bool, field string, code string) {
+	t.Helper()
+	if len(code) == 0 {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	if conflict {
+		e, ok := err.(*ConflictError)
+		if !ok || e.Code != code {
+			t.Fatalf("error = %#v, want a conflict with code %s", err, code)
+		}
+		return
+	}
+	e, ok := err.(*ValidationError)
+	if !ok || len(e.Errors) != 1 || e.Errors[0].Code != code || e.Errors[0].Field != field {
+		t.Fatalf("error = %#v, want a validation error of %s with code %s", err, field, code)
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		operations []PatchOperation
+		conflict   bool
+		field      string
+		code       string
+		check      func(p *Product) bool
+	}{
+		{"replace", []PatchOperation{op(PatchReplace, "/GeneralInfo/price", "90000")}, false, "", "",
+			func(p *Product) bool { return p.GeneralInfo.Price == 90000 }},
+		{"add a detail", []PatchOperation{op(PatchAdd, "/DetailInfo/inStockAmount", "5")}, false, "", "",
+			func(p *Product) bool { return p.DetailInfo.InStockAmount == 5 }},
+		{"remove", []PatchOperation{op(PatchRemove, "/GeneralInfo/description", "")}, false, "", "",
+			func(p *Product) bool {
+				return p.GeneralInfo.Description == "" && p.GeneralInfo.ProductName == "Iron Man"
+			}},
+		{"test then replace", []PatchOperation{op(PatchTest, "/GeneralInfo/price", "100000"), op(PatchReplace, "/GeneralInfo/price", "90000")}, false, "", "",
+			func(p *Product) bool { return p.GeneralInfo.Price == 90000 }},
+		{"test of a read-only field", []PatchOperation{op(PatchTest, "/GeneralInfo/version", "3"), op(PatchReplace, "/GeneralInfo/description", `"bricks"`)}, false, "", "",
+			func(p *Product) bool { return p.GeneralInfo.Description == "bricks" }},
+		{"failed test is a conflict", []PatchOperation{op(PatchTest, "/GeneralInfo/price", "90000"), op(PatchReplace, "/GeneralInfo/price", "80000")}, true, "", CodeTestFailed, nil},
+		{"failed test of a missing field is a conflict", []PatchOperation{op(PatchRemove, "/GeneralInfo/description", ""), op(PatchTest, "/GeneralInfo/description", `"toys"`)}, true, "", CodeTestFailed, nil},
+		{"replace of a read-only field", []PatchOperation{op(PatchReplace, "/GeneralInfo/version", "4")}, false, "[0].path", "read_only", nil},
+		{"remove of a read-only field", []PatchOperation{op(PatchRemove, "/DetailInfo/productID", "")}, false, "[0].path", "read_only", nil},
+		{"read-only field in a later operation", []PatchOperation{op(PatchReplace, "/GeneralInfo/price", "90000"), op(PatchAdd, "/GeneralInfo/status", `"archived"`)}, false, "[1].path", "read_only", nil},
+		{"unknown field", []PatchOperation{op(PatchAdd, "/GeneralInfo/color", `"red"`)}, false, "[0].path", "path", nil},
+		{"nested path", []PatchOperation{op(PatchAdd, "/GeneralInfo/price/amount", "1")}, false, "[0].path", "path", nil},
+		{"not a pointer", []PatchOperation{op(PatchAdd, "GeneralInfo/price", "1")}, false, "[0].path", "path", nil},
+		{"unknown op", []PatchOperation{op("increment", "/GeneralInfo/price", "1")}, false, "[0].op", "op", nil},
+		{"missing value", []PatchOperation{op(PatchReplace, "/GeneralInfo/price", "")}, false, "[0].value", "required", nil},
+		{"value of the wrong type", []PatchOperation{op(PatchReplace, "/GeneralInfo/description", "5")}, false, "GeneralInfo.description", "type", nil},
+		{"move", []PatchOperation{from(PatchMove, "/GeneralInfo/description", "/GeneralInfo/productName")}, false, "", "",
+			func(p *Product) bool { return p.GeneralInfo.ProductName == "toys" && p.GeneralInfo.Description == "" }},
+		{"move from a read-only field", []PatchOperation{from(PatchMove, "/GeneralInfo/id", "/GeneralInfo/description")}, false, "[0].from", "read_only", nil},
+		{"move to a read-only field", []PatchOperation{from(PatchMove, "/GeneralInfo/description", "/GeneralInfo/status")}, false, "[0].path", "read_only", nil},
+		{"move from a missing field", []PatchOperation{op(PatchRemove, "/GeneralInfo/description", ""), from(PatchMove, "/GeneralInfo/description", "/GeneralInfo/productName")}, false, "[1].from", "path", nil},
+		{"copy", []PatchOperation{from(PatchCopy, "/DetailInfo/storage", "/DetailInfo/supplier")}, false, "", "",
+			func(p *Product) bool { return p.DetailInfo.Supplier == "north" && p.DetailInfo.Storage == "north" }},
+		{"copy from a read-only field", []PatchOperation{from(PatchCopy, "/GeneralInfo/id", "/GeneralInfo/description")}, false, "", "",
+			func(p *Product) bool { return p.GeneralInfo.Description == "P001" && p.GeneralInfo.Id == "P001" }},
+		{"copy to a read-only field", []PatchOperation{from(PatchCopy, "/GeneralInfo/description", "/GeneralInfo/id")}, false, "[0].path", "read_only", nil},
+		{"copy from an unknown field", []PatchOperation{from(PatchCopy, "/GeneralInfo/color", "/GeneralInfo/description")}, false, "[0].from", "path", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := patchedProduct()
+			err := ApplyJSONPatch(product, tt.operations)
+			checkPatchError(t, err, tt.conflict, tt.field, tt.code)
+			if tt.check != nil && !tt.check(product) {
+				t.Errorf("patched product is %+v", *product)
+			}
+			if err != nil && !reflect.DeepEqual(product, patchedProduct()) {
+				t.Errorf("a failed patch changed the product to %+v", *product)
+			}
+		})
+	}
+}
+
+func TestEqualValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		current interface{}
+		value   interface{}
+		equal   bool
+	}{
+		{"same integer", json.Number("100000"), json.Number("100000"), true},
+		{"integer and exponent", json.Number("100000"), json.Number("1e5"), true},
+		{"trailing zero", json.Number("2.50"), json.Number("2.5"), true},
+		{"negative zero", json.Number("0"), json.Number("-0"), true},
+		{"different numbers", json.Number("100000"), json.Number("100001"), false},
+		{"large integers", json.Number("9007199254740993"), json.Number("9007199254740992"), false},
+		{"number and string", json.Number("1"), "1", false},
+		{"strings", "toys", "toys", true},
+		{"different strings", "toys", "Toys", false},
+		{"booleans", true, true, true},
+		{"null", nil, nil, true},
+		{"null and zero", nil, json.Number("0"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if equal := equalValues(tt.current, tt.value); equal != tt.equal {
+				t.Errorf("equalValues(%v, %v) = %v, want %v", tt.current, tt.value, equal, tt.equal)
+			}
+		})
+	}
+}
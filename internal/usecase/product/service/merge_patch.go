@@ -0,0 +1,111 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+const (
+	generalInfo = "GeneralInfo"
+	detailInfo  = "DetailInfo"
+)
+
+// MergePatch applies a JSON merge patch (RFC 7396) to the json of a product: objects are merged, null removes a field,
+// and any other value replaces it. Fields of GeneralInfo may also be at the top level of the patch, as in
+// {"price": 90000}, which is the same as {"GeneralInfo": {"price": 90000}}.
+func MergePatch(product *Product, patch map[string]interface{}) error {
+	document, err := productDocument(product)
+	if err != nil {
+		return err
+	}
+	nested := make(map[string]interface{}, len(patch))
+	general := make(map[string]interface{})
+	for key, value := range patch {
+		if key == generalInfo || key == detailInfo {
+			nested[key] = value
+		} else {
+			general[key] = value
+		}
+	}
+	if len(general) > 0 {
+		if m, ok := nested[generalInfo].(map[string]interface{}); ok {
+			for key, value := range general {
+				if _, exists := m[key]; !exists {
+					m[key] = value
+				}
+			}
+		} else if nested[generalInfo] == nil {
+			nested[generalInfo] = general
+		}
+	}
+	merged := mergeValue(document, nested)
+	return toProduct(merged, product)
+}
+
+func mergeValue(target interface{}, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = mergeValue(t[key], value)
+		}
+	}
+	return t
+}
+
+func productDocument(product *Product) (map[string]interface{}, error) {
+	data, err := json.Marshal(product)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document map[string]interface{}
+	err = decoder.Decode(&document)
+	return document, err
+}
+
+// toProduct decodes a patched document into product; a value of the wrong type or an unknown field is a validation error.
+func toProduct(document interface{}, product *Product) error {
+	data, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var patched Product
+	if err = decoder.Decode(&patched); err != nil {
+		if field, ok := unknownField(err); ok {
+			return &ValidationError{Errors: []ErrorMessage{{Field: field, Code: "unknown", Message: fmt.Sprintf("%s is not a field of product", field)}}}
+		}
+		if e, ok := err.(*json.UnmarshalTypeError); ok {
+			return &ValidationError{Errors: []ErrorMessage{{Field: e.Field, Code: "type", Param: e.Type.String(), Message: fmt.Sprintf("%s must be %s", e.Field, e.Type.String())}}}
+		}
+		return &ValidationError{Errors: []ErrorMessage{{Code: "type", Message: err.Error()}}}
+	}
+	*product = patched
+	return nil
+}
+
+// unknownField returns the field of the error of a decoder which disallows unknown fields; encoding/json tells it
+// only in the message, as: json: unknown field "name".
+func unknownField(err error) (string, bool) {
+	const prefix = "json: unknown field "
+	message := err.Error()
+	if !strings.HasPrefix(message, prefix) {
+		return "", false
+	}
+	return strings.Trim(strings.TrimPrefix(message, prefix), `"`), true
+}
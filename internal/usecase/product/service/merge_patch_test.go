@@ -0,0 +1,67 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+func mergePatchOf(t *testing.T, patch string) map[string]interface{} {
+	t.Helper()
+	decoder := json.NewDecoder(strings.NewReader(patch))
+	decoder.UseNumber()
+	var m map[string]interface{}
+	if err := decoder.Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		field string
+		code  string
+		check func(p *Product) bool
+	}{
+		{"top-level field of GeneralInfo", `{"price": 90000}`, "", "",
+			func(p *Product) bool { return p.GeneralInfo.Price == 90000 && p.GeneralInfo.ProductName == "Iron Man" }},
+		{"field of GeneralInfo", `{"GeneralInfo": {"productName": "Hulk"}}`, "", "",
+			func(p *Product) bool { return p.GeneralInfo.ProductName == "Hulk" && p.GeneralInfo.Price == 100000 }},
+		{"GeneralInfo wins over the top level", `{"price": 80000, "GeneralInfo": {"price": 90000}}`, "", "",
+			func(p *Product) bool { return p.GeneralInfo.Price == 90000 }},
+		{"top level and GeneralInfo are merged", `{"price": 80000, "GeneralInfo": {"productName": "Hulk"}}`, "", "",
+			func(p *Product) bool { return p.GeneralInfo.Price == 80000 && p.GeneralInfo.ProductName == "Hulk" }},
+		{"GeneralInfo and DetailInfo", `{"description": "bricks", "DetailInfo": {"inStockAmount": 5}}`, "", "",
+			func(p *Product) bool {
+				return p.GeneralInfo.Description == "bricks" && p.DetailInfo.InStockAmount == 5 && p.DetailInfo.Storage == "north"
+			}},
+		{"null removes a field", `{"GeneralInfo": {"description": null}, "DetailInfo": {"supplier": null}}`, "", "",
+			func(p *Product) bool {
+				return p.GeneralInfo.Description == "" && p.DetailInfo.Supplier == "" && p.DetailInfo.Storage == "north"
+			}},
+		{"null at the top level removes a field of GeneralInfo", `{"description": null}`, "", "",
+			func(p *Product) bool {
+				return p.GeneralInfo.Description == "" && p.GeneralInfo.ProductName == "Iron Man"
+			}},
+		{"empty patch", `{}`, "", "",
+			func(p *Product) bool { return reflect.DeepEqual(p, patchedProduct()) }},
+		{"unknown top-level field", `{"color": "red"}`, "color", "unknown", nil},
+		{"unknown field of DetailInfo", `{"DetailInfo": {"color": "red"}}`, "color", "unknown", nil},
+		{"value of the wrong type", `{"GeneralInfo": {"productName": 5}}`, "GeneralInfo.productName", "type", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := patchedProduct()
+			err := MergePatch(product, mergePatchOf(t, tt.patch))
+			checkPatchError(t, err, false, tt.field, tt.code)
+			if tt.check != nil && !tt.check(product) {
+				t.Errorf("patched product is %+v", *product)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "go-service/internal/usecase/product/port"
+)
+
+type OutboxRelayConfig struct {
+	Interval   time.Duration `mapstructure:"interval"`
+	BatchSize  int           `mapstructure:"batch_size"`
+	RetryDelay time.Duration `mapstructure:"retry_delay"`
+}
+
+// OutboxRelay publishes the events stored in the outbox.
+// An event is marked as published only after the publisher accepted it, so delivery is at-least-once.
+type OutboxRelay struct {
+	outbox    OutboxRepository
+	publisher EventPublisher
+	config    OutboxRelayConfig
+	logError  func(context.Context, string)
+}
+
+func NewOutboxRelay(outbox OutboxRepository, publisher EventPublisher, config OutboxRelayConfig, logError func(context.Context, string)) *OutboxRelay {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = time.Minute
+	}
+	return &OutboxRelay{outbox: outbox, publisher: publisher, config: config, logError: logError}
+}
+
+// Run relays pending events once per interval until ctx is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.Relay(ctx); err != nil {
+				r.log(ctx, fmt.Sprintf("cannot relay outbox events: %s", err.Error()))
+			}
+		}
+	}
+}
+
+// Relay publishes one batch of pending events and returns how many were published.
+// A failed event is retried after RetryDelay; it does not block the events of the other products, but the events
+// after it of the same product wait for it, so that the events of a product are published in order.
+func (r *OutboxRelay) Relay(ctx context.Context) (int, error) {
+	events, err := r.outbox.Pending(ctx, r.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	published := 0
+	failed := make(map[string]bool)
+	for _, event := range events {
+		if failed[event.ProductId] {
+			continue
+		}
+		if err = r.publisher.Publish(ctx, event); err != nil {
+			r.log(ctx, fmt.Sprintf("cannot publish event %s: %s", event.Id, err.Error()))
+			failed[event.ProductId] = true
+			if err = r.outbox.MarkFailed(ctx, event.Id, err.Error(), time.Now().Add(r.config.RetryDelay)); err != nil {
+				return published, err
+			}
+			continue
+		}
+		if err = r.outbox.MarkPublished(ctx, event.Id); err != nil {
+			return published, err
+		}
+		published++
+	}
+	return published, nil
+}
+
+func (r *OutboxRelay) log(ctx context.Context, msg string) {
+	if r.logError != nil {
+		r.logError(ctx, msg)
+	}
+}
@@ -0,0 +1,130 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-service/internal/usecase/product/adapter/publisher"
+	. "go-service/internal/usecase/product/domain"
+	. "go-service/internal/usecase/product/service"
+)
+
+// memoryOutbox keeps the pending events in the order they occurred.
+type memoryOutbox struct {
+	events    []ProductEvent
+	published []string
+	failed    []string
+}
+
+func (o *memoryOutbox) Insert(ctx context.Context, event *ProductEvent) error {
+	o.events = append(o.events, *event)
+	return nil
+}
+
+func (o *memoryOutbox) Pending(ctx context.Context, limit int) ([]ProductEvent, error) {
+	var events []ProductEvent
+	for _, event := range o.events {
+		if !contains(o.published, event.Id) && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (o *memoryOutbox) MarkPublished(ctx context.Context, id string) error {
+	o.published = append(o.published, id)
+	return nil
+}
+
+func (o *memoryOutbox) MarkFailed(ctx context.Context, id string, reason string, retryAt time.Time) error {
+	o.failed = append(o.failed, id)
+	return nil
+}
+
+// failingPublisher fails the events of its set, and publishes the others in memory.
+type failingPublisher struct {
+	*publisher.MemoryPublisher
+	fail map[string]bool
+}
+
+func (p *failingPublisher) Publish(ctx context.Context, event ProductEvent) error {
+	if p.fail[event.Id] {
+		return errors.New("unavailable")
+	}
+	return p.MemoryPublisher.Publish(ctx, event)
+}
+
+func contains(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func eventIds(events []ProductEvent) []string {
+	ids := make([]string, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.Id)
+	}
+	return ids
+}
+
+func TestOutboxRelayKeepsTheOrderOfTheEventsOfAProduct(t *testing.T) {
+	outbox := &memoryOutbox{events: []ProductEvent{
+		{Id: "e1", ProductId: "P001"},
+		{Id: "e2", ProductId: "P002"},
+		{Id: "e3", ProductId: "P001"},
+		{Id: "e4", ProductId: "P002"},
+	}}
+	pub := &failingPublisher{MemoryPublisher: publisher.NewMemoryPublisher(), fail: map[string]bool{"e1": true}}
+	relay := NewOutboxRelay(outbox, pub, OutboxRelayConfig{}, nil)
+
+	published, err := relay.Relay(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if published != 2 {
+		t.Errorf("published = %d, want 2", published)
+	}
+	if ids := eventIds(pub.Events()); !reflect.DeepEqual(ids, []string{"e2", "e4"}) {
+		t.Errorf("first pass published %v, want [e2 e4]", ids)
+	}
+	if !reflect.DeepEqual(outbox.failed, []string{"e1"}) {
+		t.Errorf("failed = %v, want [e1]: e3 waits for e1 and is not attempted", outbox.failed)
+	}
+
+	delete(pub.fail, "e1")
+	if published, err = relay.Relay(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if published != 2 {
+		t.Errorf("published = %d, want 2", published)
+	}
+	if ids := eventIds(pub.Events()); !reflect.DeepEqual(ids, []string{"e2", "e4", "e1", "e3"}) {
+		t.Errorf("published %v, want [e2 e4 e1 e3]", ids)
+	}
+}
+
+func TestOutboxRelayPublishesInBatches(t *testing.T) {
+	outbox := &memoryOutbox{}
+	for _, id := range []string{"e1", "e2", "e3"} {
+		outbox.Insert(context.Background(), &ProductEvent{Id: id, ProductId: "P001"})
+	}
+	pub := publisher.NewMemoryPublisher()
+	relay := NewOutboxRelay(outbox, pub, OutboxRelayConfig{BatchSize: 2}, nil)
+
+	if published, err := relay.Relay(context.Background()); err != nil || published != 2 {
+		t.Fatalf("Relay() = %d, %v, want 2, nil", published, err)
+	}
+	if published, err := relay.Relay(context.Background()); err != nil || published != 1 {
+		t.Fatalf("Relay() = %d, %v, want 1, nil", published, err)
+	}
+	if ids := eventIds(pub.Events()); !reflect.DeepEqual(ids, []string{"e1", "e2", "e3"}) {
+		t.Errorf("published %v, want [e1 e2 e3]", ids)
+	}
+}
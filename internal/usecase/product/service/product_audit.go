@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// NewProductAudit builds the audit entry of a mutation; before is nil on create, after is nil on delete.
+func NewProductAudit(ctx context.Context, id string, operation AuditOperation, before *Product, after *Product) (*ProductAudit, error) {
+	audit := &ProductAudit{
+		ProductId: id,
+		Operation: operation,
+		Actor:     ActorFromContext(ctx),
+		ChangedAt: time.Now(),
+	}
+	beforeFields, err := flatten(before, &audit.Before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := flatten(after, &audit.After)
+	if err != nil {
+		return nil, err
+	}
+	audit.Diff = diff(beforeFields, afterFields)
+	return audit, nil
+}
+
+// flatten marshals the product into data, and returns the json fields of GeneralInfo and DetailInfo in one map.
+func flatten(product *Product, data *json.RawMessage) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	if product == nil {
+		return fields, nil
+	}
+	b, err := json.Marshal(product)
+	if err != nil {
+		return nil, err
+	}
+	*data = b
+	var parts map[string]interface{}
+	if err = json.Unmarshal(b, &parts); err != nil {
+		return nil, err
+	}
+	for key, part := range parts {
+		if m, ok := part.(map[string]interface{}); ok {
+			for k, v := range m {
+				fields[k] = v
+			}
+		} else {
+			fields[key] = part
+		}
+	}
+	return fields, nil
+}
+
+func diff(before map[string]interface{}, after map[string]interface{}) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+	for k, v := range before {
+		if w, ok := after[k]; !ok || !reflect.DeepEqual(v, w) {
+			changes[k] = FieldChange{From: v, To: after[k]}
+		}
+	}
+	for k, w := range after {
+		if _, ok := before[k]; !ok {
+			changes[k] = FieldChange{To: w}
+		}
+	}
+	return changes
+}
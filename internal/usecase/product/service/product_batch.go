@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+func (s *productService) CreateBatch(ctx context.Context, products []Product, atomic bool) ([]ResultInfo, error) {
+	check := func(ctx context.Context, i int) ([]ErrorMessage, error) {
+		if err := deriveStock(nil, &products[i]); err != nil {
+			return nil, err
+		}
+		derivePrice(nil, &products[i])
+		return s.checkDerived(ctx, nil, &products[i])
+	}
+	return s.runBatch(ctx, len(products), atomic, nil, check, func(ctx context.Context, items []int) error {
+		batch := selectProducts(products, items)
+		if _, err := s.repository.CreateBatch(ctx, batch); err != nil {
+			return err
+		}
+		for i := range batch {
+			if err := s.recordChange(ctx, batch[i].GeneralInfo.Id, OperationCreate, nil, &batch[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpsertBatch creates or replaces products; as in Update, the DetailInfo of a product without stocks sets the stock
+// of its storage, the other stocks of the product are kept, and so is its status. The products are locked before
+// they are read, then each one is derived from its current state and validated. A deleted product must be restored first.
+func (s *productService) UpsertBatch(ctx context.Context, products []Product, atomic bool) ([]ResultInfo, error) {
+	var before map[string]*Product
+	var deleted map[string]bool
+	lock := func(ctx context.Context) error {
+		var err error
+		before, deleted, err = s.repository.LoadManyForUpdate(ctx, productIds(products))
+		return err
+	}
+	check := func(ctx context.Context, i int) ([]ErrorMessage, error) {
+		current := before[products[i].GeneralInfo.Id]
+		if err := deriveStock(current, &products[i]); err != nil {
+			return nil, err
+		}
+		derivePrice(current, &products[i])
+		return s.checkDerived(ctx, current, &products[i])
+	}
+	return s.runBatch(ctx, len(products), atomic, lock, check, func(ctx context.Context, items []int) error {
+		batch := selectProducts(products, items)
+		ids := productIds(batch)
+		for _, id := range ids {
+			if deleted[id] {
+				return &ConflictError{Resource: "product", Id: id, Code: CodeDeleted, Message: fmt.Sprintf("product '%s' is deleted, and must be restored first", id)}
+			}
+		}
+		if _, err := s.repository.UpsertBatch(ctx, batch); err != nil {
+			return err
+		}
+		after, err := s.repository.LoadMany(ctx, ids)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			operation := OperationUpdate
+			if before[id] == nil {
+				operation = OperationCreate
+			}
+			if err = s.recordChange(ctx, id, operation, before[id], after[id]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteBatch deletes products, which are locked before they are read for their audit entries.
+func (s *productService) DeleteBatch(ctx context.Context, ids []string, atomic bool) ([]ResultInfo, error) {
+	var before map[string]*Product
+	lock := func(ctx context.Context) error {
+		var err error
+		before, _, err = s.repository.LoadManyForUpdate(ctx, ids)
+		return err
+	}
+	check := func(ctx context.Context, i int) ([]ErrorMessage, error) {
+		if len(ids[i]) == 0 {
+			return []ErrorMessage{{Field: "id", Code: "required", Message: "id is required"}}, nil
+		}
+		return nil, nil
+	}
+	return s.runBatch(ctx, len(ids), atomic, lock, check, func(ctx context.Context, items []int) error {
+		batch := make([]string, len(items))
+		for i, item := range items {
+			batch[i] = ids[item]
+		}
+		for _, id := range batch {
+			if before[id] == nil {
+				return &NotFoundError{Resource: "product", Id: id}
+			}
+		}
+		if _, err := s.repository.DeleteBatch(ctx, batch); err != nil {
+			return err
+		}
+		for _, id := range batch {
+			if err := s.recordChange(ctx, id, OperationDelete, before[id], nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// checkDerived checks the status of a derived product, then validates it; the errors of validation are returned
+// as the errors of the item.
+func (s *productService) checkDerived(ctx context.Context, current *Product, product *Product) ([]ErrorMessage, error) {
+	if err := s.checkStatus(current, product); err != nil {
+		var validation *ValidationError
+		if errors.As(err, &validation) {
+			return validation.Errors, nil
+		}
+		return nil, err
+	}
+	return s.check(ctx, product)
+}
+
+// runBatch runs in one transaction: it locks the items with lock, when it is set, validates every item with check,
+// then writes the valid ones with writeAll, and returns one result per item.
+// In atomic mode, nothing is written unless every item is valid and the whole batch succeeds.
+// In best-effort mode, when writing all the valid items fails, they are written one by one in savepoints,
+// so that each item gets its own result and a failed item does not prevent the others.
+func (s *productService) runBatch(ctx context.Context, size int, atomic bool, lock func(ctx context.Context) error, check func(ctx context.Context, i int) ([]ErrorMessage, error), writeAll func(ctx context.Context, items []int) error) ([]ResultInfo, error) {
+	results := make([]ResultInfo, size)
+	valid := make([]int, 0, size)
+	var invalid []ErrorMessage
+	err := s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if lock != nil {
+			if err := lock(ctx); err != nil {
+				return err
+			}
+		}
+		for i := 0; i < size; i++ {
+			errs, err := check(ctx, i)
+			if err != nil {
+				return err
+			}
+			if len(errs) > 0 {
+				results[i] = ResultInfo{Status: -1, Errors: errs, Message: CodeValidation}
+				for _, e := range errs {
+					e.Field = fmt.Sprintf("[%d].%s", i, e.Field)
+					invalid = append(invalid, e)
+				}
+				continue
+			}
+			valid = append(valid, i)
+		}
+		if atomic && len(invalid) > 0 {
+			return &ValidationError{Errors: invalid}
+		}
+		if len(valid) == 0 {
+			return nil
+		}
+		err := s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+			return writeAll(ctx, valid)
+		})
+		if err == nil || atomic {
+			return err
+		}
+		for _, i := range valid {
+			item := []int{i}
+			if er2 := s.unitOfWork.Do(ctx, func(ctx context.Context) error { return writeAll(ctx, item) }); er2 != nil {
+				results[i] = failedResult(er2)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if atomic && len(invalid) > 0 {
+			return results, err
+		}
+		return nil, err
+	}
+	for _, i := range valid {
+		if results[i].Status == 0 && len(results[i].Errors) == 0 {
+			results[i].Status = 1
+		}
+	}
+	return results, nil
+}
+
+// failedResult reports the failure of one item; as in single writes, 0 means not found or duplicate, and -1 an error.
+func failedResult(err error) ResultInfo {
+	var notFound *NotFoundError
+	var conflict *ConflictError
+	var validation *ValidationError
+	switch {
+	case errors.As(err, &notFound):
+		return ResultInfo{Status: 0, Errors: []ErrorMessage{{Field: "id", Code: CodeNotFound, Message: notFound.Error()}}, Message: CodeNotFound}
+	case errors.As(err, &conflict):
+		return ResultInfo{Status: 0, Errors: []ErrorMessage{{Field: "id", Code: conflict.Code, Message: conflict.Error()}}, Message: conflict.Code}
+	case errors.As(err, &validation):
+		return ResultInfo{Status: -1, Errors: validation.Errors, Message: CodeValidation}
+	default:
+		return ResultInfo{Status: -1, Message: err.Error()}
+	}
+}
+
+func selectProducts(products []Product, items []int) []Product {
+	batch := make([]Product, len(items))
+	for i, item := range items {
+		batch[i] = products[item]
+	}
+	return batch
+}
+
+func productIds(products []Product) []string {
+	ids := make([]string, len(products))
+	for i := range products {
+		ids[i] = products[i].GeneralInfo.Id
+	}
+	return ids
+}
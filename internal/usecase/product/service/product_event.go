@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// NewProductEvent builds the event of an audited operation; after is nil when the product has been deleted.
+func NewProductEvent(ctx context.Context, id string, operation AuditOperation, after *Product) (*ProductEvent, error) {
+	eventId, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+	return &ProductEvent{
+		Id:         eventId,
+		Type:       EventTypeOf(operation),
+		ProductId:  id,
+		Actor:      ActorFromContext(ctx),
+		OccurredAt: time.Now(),
+		Product:    after,
+	}, nil
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+type LifecycleConfig struct {
+	Initial     string              `mapstructure:"initial"`
+	Transitions map[string][]string `mapstructure:"transitions"`
+}
+
+// NewLifecycle builds the lifecycle of a configuration, or returns the default lifecycle when it is empty.
+func NewLifecycle(config LifecycleConfig) (*Lifecycle, error) {
+	if len(config.Initial) == 0 && len(config.Transitions) == 0 {
+		return DefaultLifecycle(), nil
+	}
+	lifecycle := &Lifecycle{Initial: ProductStatus(config.Initial), Transitions: make(map[ProductStatus][]ProductStatus, len(config.Transitions))}
+	if !lifecycle.Initial.Valid() {
+		return nil, fmt.Errorf("invalid initial status %q", config.Initial)
+	}
+	for from, statuses := range config.Transitions {
+		if !ProductStatus(from).Valid() {
+			return nil, fmt.Errorf("invalid status %q in transitions", from)
+		}
+		for _, to := range statuses {
+			if !ProductStatus(to).Valid() {
+				return nil, fmt.Errorf("invalid status %q in transitions from %q", to, from)
+			}
+			lifecycle.Transitions[ProductStatus(from)] = append(lifecycle.Transitions[ProductStatus(from)], ProductStatus(to))
+		}
+	}
+	return lifecycle, nil
+}
+
+// lifecycleGuards are the conditions a product must meet to be in a status.
+var lifecycleGuards = map[ProductStatus]func(product *Product) []ErrorMessage{
+	StatusActive:   activeGuard,
+	StatusArchived: archivedGuard,
+}
+
+// activeGuard requires what a product needs to be sold.
+func activeGuard(product *Product) []ErrorMessage {
+	var errs []ErrorMessage
+	g := product.GeneralInfo
+	if len(g.ProductName) == 0 {
+		errs = append(errs, ErrorMessage{Field: "productName", Code: "required", Message: "productName is required to activate a product"})
+	}
+	if g.Price <= 0 {
+		errs = append(errs, ErrorMessage{Field: "price", Code: "required", Message: "price is required to activate a product"})
+	}
+	if !g.Currency.Valid() {
+		errs = append(errs, ErrorMessage{Field: "currency", Code: "required", Message: "currency is required to activate a product"})
+	}
+	return errs
+}
+
+// archivedGuard requires that an archived product has no stock left.
+func archivedGuard(product *Product) []ErrorMessage {
+	if TotalStock(product.Stocks) > 0 {
+		return []ErrorMessage{{Field: "inStockAmount", Code: "max", Param: "0", Message: "a product which is in stock cannot be archived"}}
+	}
+	return nil
+}
+
+func checkGuard(product *Product) error {
+	if guard, ok := lifecycleGuards[product.GeneralInfo.Status]; ok {
+		if errs := guard(product); len(errs) > 0 {
+			return &ValidationError{Errors: errs}
+		}
+	}
+	return nil
+}
+
+// checkStatus sets the status a product is written with. A new product has the initial status of the lifecycle,
+// or a status it can move to; otherwise, the product keeps its current status, which only a transition changes.
+// The product must meet the guard of its status.
+func (s *productService) checkStatus(current *Product, product *Product) error {
+	status := product.GeneralInfo.Status
+	if current == nil {
+		if len(status) == 0 {
+			status = s.lifecycle.Initial
+		} else if !s.lifecycle.AllowsNew(status) {
+			return &ValidationError{Errors: []ErrorMessage{{Field: "status", Code: CodeTransition, Message: fmt.Sprintf("a product cannot be created as %s", status)}}}
+		}
+	} else {
+		if len(status) > 0 && status != current.GeneralInfo.Status {
+			return &ValidationError{Errors: []ErrorMessage{{Field: "status", Code: CodeTransition, Message: fmt.Sprintf("status is %s, and is changed by a transition", current.GeneralInfo.Status)}}}
+		}
+		status = current.GeneralInfo.Status
+	}
+	product.GeneralInfo.Status = status
+	return checkGuard(product)
+}
+
+// Transition moves a product to another status, if the lifecycle allows it and the product meets the guard of the status.
+func (s *productService) Transition(ctx context.Context, id string, version int64, transition ProductTransition) (int64, error) {
+	errs, err := s.validator.Validate(ctx, &transition)
+	if err != nil {
+		return -1, err
+	}
+	if len(errs) > 0 {
+		return -1, &ValidationError{Errors: errs}
+	}
+	return s.mutate(ctx, id, OperationTransition, func(ctx context.Context) (int64, error) {
+		product, err := s.repository.Load(ctx, id)
+		if err != nil {
+			return -1, requireExisting(err, version)
+		}
+		if version > 0 && version != product.GeneralInfo.Version {
+			return -1, &VersionMismatchError{Resource: "product", Id: id}
+		}
+		from := product.GeneralInfo.Status
+		if !s.lifecycle.Allows(from, transition.To) {
+			return -1, &ConflictError{Resource: "product", Id: id, Code: CodeTransition, Message: fmt.Sprintf("product '%s' cannot move from %s to %s", id, from, transition.To)}
+		}
+		product.GeneralInfo.Status = transition.To
+		if err = checkGuard(product); err != nil {
+			return -1, err
+		}
+		if transition.To == StatusArchived {
+			// a released reservation gives its quantity back to the stock, which an archived product cannot have
+			reserved, err := s.reservationRepository.Reserved(ctx, id)
+			if err != nil {
+				return -1, err
+			}
+			if reserved > 0 {
+				return -1, &ValidationError{Errors: []ErrorMessage{{Field: "reserved", Code: "max", Param: "0", Message: "a product which has held reservations cannot be archived"}}}
+			}
+		}
+		return s.repository.UpdateStatus(ctx, id, product.GeneralInfo.Version, transition.To)
+	})
+}
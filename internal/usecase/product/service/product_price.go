@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+type PriceConfig struct {
+	MaterializeInterval time.Duration `mapstructure:"materialize_interval"`
+	BatchSize           int           `mapstructure:"batch_size"`
+	RetryDelay          time.Duration `mapstructure:"retry_delay"`
+}
+
+// maxPriceRetryDelay bounds the delay before the next attempt to store the price of a product, which doubles on each failure.
+const maxPriceRetryDelay = 24 * time.Hour
+
+// SchedulePrice schedules a price of a product. The product is read with it as soon as it is in effect,
+// and MaterializePrices stores it in the price of the product, and records it in the price history.
+func (s *productService) SchedulePrice(ctx context.Context, id string, price *ScheduledPrice) error {
+	errs, err := s.validator.Validate(ctx, price)
+	if err != nil {
+		return err
+	}
+	if price.EffectiveTo != nil && !price.EffectiveTo.After(price.EffectiveFrom) {
+		errs = append(errs, ErrorMessage{Field: "effectiveTo", Code: "min", Message: "effectiveTo must be after effectiveFrom"})
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	if price.Id, err = newUUID(); err != nil {
+		return err
+	}
+	price.ProductId = id
+	price.Actor = ActorFromContext(ctx)
+	price.CreatedAt = time.Now()
+	return s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if _, err := s.repository.Load(ctx, id); err != nil {
+			return err
+		}
+		return s.priceRepository.Insert(ctx, price)
+	})
+}
+
+// ScheduledPrices returns the scheduled prices of a product, the latest to start first, and their total.
+func (s *productService) ScheduledPrices(ctx context.Context, id string, limit int64, offset int64) ([]ScheduledPrice, int64, error) {
+	return s.priceRepository.List(ctx, id, limit, offset)
+}
+
+// PriceHistory returns the prices a product has had in effect, most recent first, and their total.
+func (s *productService) PriceHistory(ctx context.Context, id string, limit int64, offset int64) ([]PriceChange, int64, error) {
+	return s.priceRepository.History(ctx, id, limit, offset)
+}
+
+// MaterializePrices stores the prices in effect at now of up to limit products, in the price of each product,
+// with its base price while the price is scheduled. A price which is not the last one of the price history of
+// a product is recorded in it, with a PriceChanged event. Each product is updated in its own transaction, and a
+// product which has changed in the meantime is retried. A product which cannot be updated is skipped and is not
+// pending again before retryDelay, doubled on each failure, so that it does not hold back the others; the returned
+// error tells all the products which were skipped.
+func (s *productService) MaterializePrices(ctx context.Context, now time.Time, limit int, retryDelay time.Duration) (int64, error) {
+	pending, err := s.priceRepository.Pending(ctx, now, limit)
+	if err != nil {
+		return 0, err
+	}
+	var materialized int64
+	var failures []string
+	for _, p := range pending {
+		var n int64
+		err := s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+			var err error
+			price, basePrice := p.Price, p.BasePrice
+			if p.ScheduledPrice != nil {
+				if basePrice == nil {
+					basePrice = &p.Price
+				}
+				price = *p.ScheduledPrice
+			} else {
+				price, basePrice = *p.BasePrice, nil
+			}
+			if n, err = s.repository.UpdatePrice(ctx, p.ProductId, p.Version, price, basePrice); err != nil {
+				return err
+			}
+			if n == 0 {
+				return &VersionMismatchError{Resource: "product", Id: p.ProductId}
+			}
+			if p.Attempts > 0 {
+				if err = s.priceRepository.ClearFailure(ctx, p.ProductId); err != nil {
+					return err
+				}
+			}
+			return s.changePrice(ctx, p.ProductId, p.Price, price, p.ScheduleId, now)
+		})
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("product '%s': %s", p.ProductId, err.Error()))
+			if err = s.priceRepository.MarkFailed(ctx, p.ProductId, err.Error(), now.Add(priceRetryDelay(retryDelay, p.Attempts))); err != nil {
+				return materialized, err
+			}
+			continue
+		}
+		materialized += n
+	}
+	if len(failures) > 0 {
+		return materialized, fmt.Errorf("cannot materialize the prices of %d products: %s", len(failures), strings.Join(failures, "; "))
+	}
+	return materialized, nil
+}
+
+// priceRetryDelay is the delay before the next attempt to store the price of a product which failed attempts times before.
+func priceRetryDelay(retryDelay time.Duration, attempts int) time.Duration {
+	delay := retryDelay
+	for i := 0; i < attempts && delay < maxPriceRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxPriceRetryDelay {
+		return maxPriceRetryDelay
+	}
+	return delay
+}
+
+// changePrice records that the price in effect of a product is price from now, unless it is already the last one of
+// its price history; stored is the price of the product before, which is the previous one when it has no history.
+func (s *productService) changePrice(ctx context.Context, id string, stored Amount, price Amount, scheduleId string, now time.Time) error {
+	previous := stored
+	latest, err := s.priceRepository.LatestChange(ctx, id)
+	if err != nil {
+		return err
+	}
+	if latest != nil {
+		previous = latest.Price
+	}
+	if previous == price {
+		return nil
+	}
+	change := &PriceChange{ProductId: id, Price: price, PreviousPrice: &previous, ScheduleId: scheduleId, Actor: ActorFromContext(ctx), ChangedAt: now}
+	if err = s.priceRepository.InsertChange(ctx, change); err != nil {
+		return err
+	}
+	after, err := s.repository.Load(ctx, id)
+	if err != nil {
+		return err
+	}
+	before := *after
+	before.GeneralInfo.Price = previous
+	return s.recordChange(ctx, id, OperationPrice, &before, after)
+}
+
+// derivePrice sets the price a product is written with. While a scheduled price is in effect, the product is read with
+// it as Price and with its own price as BasePrice, so it keeps BasePrice unless it is written with another price.
+// The product is written without the scheduled price, which MaterializePrices stores again.
+func derivePrice(current *Product, product *Product) {
+	g := &product.GeneralInfo
+	if current != nil && current.GeneralInfo.BasePrice != nil && g.Price == current.GeneralInfo.Price {
+		g.Price = *current.GeneralInfo.BasePrice
+	}
+	g.BasePrice = nil
+}
+
+// PriceMaterializer periodically stores the prices which have come into effect, or out of effect, in the products.
+type PriceMaterializer struct {
+	service  ProductService
+	config   PriceConfig
+	logError func(context.Context, string)
+}
+
+func NewPriceMaterializer(service ProductService, config PriceConfig, logError func(context.Context, string)) *PriceMaterializer {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = time.Minute
+	}
+	return &PriceMaterializer{service: service, config: config, logError: logError}
+}
+
+// Run materializes prices once per interval until ctx is cancelled.
+func (j *PriceMaterializer) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.config.MaterializeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := j.service.MaterializePrices(ctx, time.Now(), j.config.BatchSize, j.config.RetryDelay); err != nil && j.logError != nil {
+				j.logError(ctx, fmt.Sprintf("cannot materialize prices: %s", err.Error()))
+			}
+		}
+	}
+}
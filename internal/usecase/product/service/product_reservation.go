@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+type ReservationConfig struct {
+	TTL           time.Duration `mapstructure:"ttl"`
+	SweepInterval time.Duration `mapstructure:"sweep_interval"`
+	BatchSize     int           `mapstructure:"batch_size"`
+}
+
+// Reserve holds a quantity of a product, taken from its stock in the storage of the reservation, or in the storage
+// which has the most stock. The reservation is held until it is confirmed, released, or it expires.
+func (s *productService) Reserve(ctx context.Context, id string, reservation *Reservation) error {
+	errs, err := s.validator.Validate(ctx, reservation)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	if reservation.Id, err = newUUID(); err != nil {
+		return err
+	}
+	now := time.Now()
+	reservation.ProductId = id
+	reservation.Status = ReservationHeld
+	reservation.Actor = ActorFromContext(ctx)
+	reservation.CreatedAt = now
+	reservation.ExpiresAt = now.Add(s.reservationTTL)
+	reservation.UpdatedAt = nil
+	return s.mutateStock(ctx, id, func(ctx context.Context, product *Product) error {
+		if len(reservation.Storage) == 0 {
+			reservation.Storage = largestStock(product.Stocks)
+		}
+		movement := &StockMovement{ProductId: id, Storage: reservation.Storage, Delta: -reservation.Quantity, Reason: MovementReservation, Reference: reservation.Id}
+		if err := s.adjustStock(ctx, product, movement); err != nil {
+			return err
+		}
+		return s.reservationRepository.Insert(ctx, reservation)
+	})
+}
+
+// ConfirmReservation confirms a held reservation: its quantity is no longer part of the stock, and it does not expire.
+func (s *productService) ConfirmReservation(ctx context.Context, id string, reservationId string) (*Reservation, error) {
+	var reservation *Reservation
+	err := s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		reservation, err = s.endReservation(ctx, id, reservationId, ReservationConfirmed)
+		return err
+	})
+	return reservation, err
+}
+
+// ReleaseReservation releases a held reservation, which gives its quantity back to the stock.
+func (s *productService) ReleaseReservation(ctx context.Context, id string, reservationId string) (*Reservation, error) {
+	return s.releaseReservation(ctx, id, reservationId, ReservationReleased)
+}
+
+// ExpireReservations releases, as expired, up to limit held reservations which expire before a time,
+// and returns how many were expired. The failure of a reservation does not stop the others; the failures are returned
+// together.
+func (s *productService) ExpireReservations(ctx context.Context, before time.Time, limit int) (int64, error) {
+	reservations, err := s.reservationRepository.Expired(ctx, before, limit)
+	if err != nil {
+		return 0, err
+	}
+	var expired int64
+	var failures []string
+	for i := range reservations {
+		err := s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+			return s.expireReservation(ctx, &reservations[i])
+		})
+		var conflict *ConflictError
+		if errors.As(err, &conflict) {
+			// it has been confirmed or released meanwhile
+			continue
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("reservation '%s': %s", reservations[i].Id, err.Error()))
+			continue
+		}
+		expired++
+	}
+	if len(failures) > 0 {
+		return expired, fmt.Errorf("cannot expire %d reservations: %s", len(failures), strings.Join(failures, "; "))
+	}
+	return expired, nil
+}
+
+// expireReservation releases an expired reservation, in the transaction of ctx. The quantity of a reservation of
+// a deleted product is given back to its stock without an audit entry nor an event, so that the product has it when
+// it is restored; the reservation of a purged product just expires.
+func (s *productService) expireReservation(ctx context.Context, reservation *Reservation) error {
+	_, err := s.repository.LoadForUpdate(ctx, reservation.ProductId)
+	if err == nil {
+		_, err = s.releaseReservation(ctx, reservation.ProductId, reservation.Id, ReservationExpired)
+		return err
+	}
+	if _, ok := err.(*NotFoundError); ok {
+		_, err = s.endReservation(ctx, reservation.ProductId, reservation.Id, ReservationExpired)
+		return err
+	}
+	if conflict, ok := err.(*ConflictError); !ok || conflict.Code != CodeDeleted {
+		return err
+	}
+	if _, err = s.endReservation(ctx, reservation.ProductId, reservation.Id, ReservationExpired); err != nil {
+		return err
+	}
+	movement := &StockMovement{ProductId: reservation.ProductId, Storage: reservation.Storage, Delta: reservation.Quantity, Reason: MovementRelease, Reference: reservation.Id}
+	_, err = s.moveStock(ctx, movement)
+	return err
+}
+
+func (s *productService) releaseReservation(ctx context.Context, id string, reservationId string, status ReservationStatus) (*Reservation, error) {
+	var reservation *Reservation
+	err := s.mutateStock(ctx, id, func(ctx context.Context, product *Product) error {
+		var err error
+		if reservation, err = s.endReservation(ctx, id, reservationId, status); err != nil {
+			return err
+		}
+		movement := &StockMovement{ProductId: id, Storage: reservation.Storage, Delta: reservation.Quantity, Reason: MovementRelease, Reference: reservation.Id}
+		return s.adjustStock(ctx, product, movement)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// endReservation changes a held reservation of a product to status; the change is conditional,
+// so that a reservation cannot be both confirmed and released by concurrent requests.
+func (s *productService) endReservation(ctx context.Context, id string, reservationId string, status ReservationStatus) (*Reservation, error) {
+	reservation, err := s.reservationRepository.Load(ctx, reservationId)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.ProductId != id {
+		return nil, &NotFoundError{Resource: "reservation", Id: reservationId}
+	}
+	n, err := s.reservationRepository.UpdateStatus(ctx, reservationId, ReservationHeld, status)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, &ConflictError{Resource: "reservation", Id: reservationId, Code: CodeNotHeld, Message: fmt.Sprintf("reservation '%s' is %s", reservationId, reservation.Status)}
+	}
+	reservation.Status = status
+	return reservation, nil
+}
+
+// largestStock returns the storage which has the most stock.
+func largestStock(stocks []ProductDetails) string {
+	storage, amount := "", 0
+	for _, stock := range stocks {
+		if stock.InStockAmount > amount {
+			storage, amount = stock.Storage, stock.InStockAmount
+		}
+	}
+	return storage
+}
+
+// ReservationSweeper periodically expires the held reservations which have not been confirmed in time.
+type ReservationSweeper struct {
+	service  ProductService
+	config   ReservationConfig
+	logError func(context.Context, string)
+}
+
+func NewReservationSweeper(service ProductService, config ReservationConfig, logError func(context.Context, string)) *ReservationSweeper {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	return &ReservationSweeper{service: service, config: config, logError: logError}
+}
+
+// Run expires reservations once per interval until ctx is cancelled.
+func (j *ReservationSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.config.SweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := j.service.ExpireReservations(ctx, time.Now(), j.config.BatchSize); err != nil && j.logError != nil {
+				j.logError(ctx, fmt.Sprintf("cannot expire reservations: %s", err.Error()))
+			}
+		}
+	}
+}
@@ -2,143 +2,390 @@ package service
 
 import (
 	"context"
-	"database/sql"
-	"errors"
+	"time"
+
 	. "go-service/internal/usecase/product/domain"
 	. "go-service/internal/usecase/product/port"
 )
 
 type ProductService interface {
 	Load(ctx context.Context, id string) (*Product, error)
+	LoadProjection(ctx context.Context, id string, projection *ProductProjection) (*Product, error)
 	Create(ctx context.Context, product *Product) (int64, error)
 	Update(ctx context.Context, product *Product) (int64, error)
-	Patch(ctx context.Context, product map[string]interface{}) (int64, error)
-	Delete(ctx context.Context, id string) (int64, error)
+	Upsert(ctx context.Context, product *Product) (bool, error)
+	// Patch and JSONPatch return the new version of the product.
+	Patch(ctx context.Context, id string, version int64, patch map[string]interface{}) (int64, error)
+	JSONPatch(ctx context.Context, id string, version int64, operations []PatchOperation) (int64, error)
+	Delete(ctx context.Context, id string, version int64) (int64, error)
+	Restore(ctx context.Context, id string, version int64) (int64, error)
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	History(ctx context.Context, id string, limit int64, offset int64) ([]ProductAudit, int64, error)
+	CreateBatch(ctx context.Context, products []Product, atomic bool) ([]ResultInfo, error)
+	UpsertBatch(ctx context.Context, products []Product, atomic bool) ([]ResultInfo, error)
+	DeleteBatch(ctx context.Context, ids []string, atomic bool) ([]ResultInfo, error)
+	Export(ctx context.Context, filter *ProductFilter, write func(product *Product) error) error
+	Stocks(ctx context.Context, id string) ([]ProductDetails, error)
+	SetStock(ctx context.Context, id string, version int64, stock ProductDetails) (int64, error)
+	Reserve(ctx context.Context, id string, reservation *Reservation) error
+	ConfirmReservation(ctx context.Context, id string, reservationId string) (*Reservation, error)
+	ReleaseReservation(ctx context.Context, id string, reservationId string) (*Reservation, error)
+	ExpireReservations(ctx context.Context, before time.Time, limit int) (int64, error)
+	MoveStock(ctx context.Context, id string, movement *StockMovement) error
+	StockMovements(ctx context.Context, id string, limit int64, offset int64) ([]StockMovement, int64, error)
+	Transition(ctx context.Context, id string, version int64, transition ProductTransition) (int64, error)
+	SchedulePrice(ctx context.Context, id string, price *ScheduledPrice) error
+	ScheduledPrices(ctx context.Context, id string, limit int64, offset int64) ([]ScheduledPrice, int64, error)
+	PriceHistory(ctx context.Context, id string, limit int64, offset int64) ([]PriceChange, int64, error)
+	MaterializePrices(ctx context.Context, now time.Time, limit int, retryDelay time.Duration) (int64, error)
 }
 
-func NewProductService(db *sql.DB, repository ProductRepository) ProductService {
+// NewProductService creates the product service; the statuses of products follow lifecycle,
+// and reservations are held for reservationTTL unless they are confirmed.
+func NewProductService(unitOfWork UnitOfWork, repository ProductRepository, auditRepository ProductAuditRepository, outboxRepository OutboxRepository, reservationRepository ReservationRepository, movementRepository StockMovementRepository, priceRepository PriceRepository, validator *Validator, lifecycle *Lifecycle, reservationTTL time.Duration) ProductService {
 	return &productService{
-		db:         db,
-		repository: repository,
+		unitOfWork:            unitOfWork,
+		repository:            repository,
+		auditRepository:       auditRepository,
+		outboxRepository:      outboxRepository,
+		reservationRepository: reservationRepository,
+		movementRepository:    movementRepository,
+		priceRepository:       priceRepository,
+		validator:             validator,
+		lifecycle:             lifecycle,
+		reservationTTL:        reservationTTL,
 	}
 }
 
 type productService struct {
-	db         *sql.DB
-	repository ProductRepository
+	unitOfWork            UnitOfWork
+	repository            ProductRepository
+	auditRepository       ProductAuditRepository
+	outboxRepository      OutboxRepository
+	reservationRepository ReservationRepository
+	movementRepository    StockMovementRepository
+	priceRepository       PriceRepository
+	validator             *Validator
+	lifecycle             *Lifecycle
+	reservationTTL        time.Duration
 }
 
+// Load loads a product, with its stock which is available and the stock which is held by reservations.
 func (s *productService) Load(ctx context.Context, id string) (*Product, error) {
-	return s.repository.Load(ctx, id)
-}
-func (s *productService) Create(ctx context.Context, product *Product) (int64, error) {
-	err := checkProductGeneralReq(product.GeneralInfo)
+	product, err := s.repository.Load(ctx, id)
 	if err != nil {
-		return -1, nil
+		return nil, err
 	}
-	tx, err := s.db.Begin()
+	reserved, err := s.reservationRepository.Reserved(ctx, id)
 	if err != nil {
-		return -1, nil
+		return nil, err
 	}
-	ctx = context.WithValue(ctx, "tx", tx)
-	res, err := s.repository.Create(ctx, product)
-	if err != nil {
-		er2 := tx.Rollback()
-		if er2 != nil {
-			return -1, er2
-		}
+	product.Availability = &ProductAvailability{Available: TotalStock(product.Stocks), Reserved: reserved}
+	return product, nil
+}
+func (s *productService) LoadProjection(ctx context.Context, id string, projection *ProductProjection) (*Product, error) {
+	return s.repository.LoadProjection(ctx, id, projection)
+}
+func (s *productService) Create(ctx context.Context, product *Product) (int64, error) {
+	if err := deriveStock(nil, product); err != nil {
+		return -1, err
 	}
-	if err = tx.Commit(); err != nil {
-		er2 := tx.Rollback()
-		if er2 != nil {
-			return -1, er2
-		}
+	derivePrice(nil, product)
+	if err := s.checkStatus(nil, product); err != nil {
+		return -1, err
+	}
+	if err := s.validate(ctx, product); err != nil {
+		return -1, err
 	}
-	return res, err
+	return s.mutate(ctx, product.GeneralInfo.Id, OperationCreate, func(ctx context.Context) (int64, error) {
+		return s.repository.Create(ctx, product)
+	})
 }
+
+// Update replaces a product; without stocks, its DetailInfo sets the stock of its storage and the other stocks are kept.
 func (s *productService) Update(ctx context.Context, product *Product) (int64, error) {
-	tx, err := s.db.Begin()
+	id := product.GeneralInfo.Id
+	return s.mutate(ctx, id, OperationUpdate, func(ctx context.Context) (int64, error) {
+		current, err := s.loadIfExists(ctx, id)
+		if err != nil {
+			return -1, err
+		}
+		if err = deriveStock(current, product); err != nil {
+			return -1, err
+		}
+		derivePrice(current, product)
+		if err = s.checkStatus(current, product); err != nil {
+			return -1, err
+		}
+		if err = s.validate(ctx, product); err != nil {
+			return -1, err
+		}
+		return s.repository.Update(ctx, product)
+	})
+}
+
+// Upsert creates a product, or replaces it if it exists, and tells whether it was created. A soft deleted product
+// is a conflict: it must be restored first. The version of the product, when set, must be the current one;
+// with AnyVersion, the product must exist.
+// The product is locked before it is read, so that concurrent requests cannot both create it or both pass the version check.
+func (s *productService) Upsert(ctx context.Context, product *Product) (bool, error) {
+	id := product.GeneralInfo.Id
+	created := false
+	err := s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		current, err := s.repository.LoadForUpdate(ctx, id)
+		if _, ok := err.(*NotFoundError); ok {
+			current, err = nil, nil
+		}
+		if err != nil {
+			return err
+		}
+		expected := product.GeneralInfo.Version
+		if expected != 0 && current == nil {
+			return &VersionMismatchError{Resource: "product", Id: id, Missing: true}
+		}
+		if expected > 0 && current.GeneralInfo.Version != expected {
+			return &VersionMismatchError{Resource: "product", Id: id}
+		}
+		if err = deriveStock(current, product); err != nil {
+			return err
+		}
+		derivePrice(current, product)
+		if err = s.checkStatus(current, product); err != nil {
+			return err
+		}
+		if err = s.validate(ctx, product); err != nil {
+			return err
+		}
+		operation := OperationUpdate
+		if created = current == nil; created {
+			operation = OperationCreate
+		}
+		if _, err = s.record(ctx, id, operation, func(ctx context.Context) (int64, error) {
+			return s.repository.Upsert(ctx, product)
+		}); err != nil {
+			return err
+		}
+		after, err := s.repository.Load(ctx, id)
+		if err != nil {
+			return err
+		}
+		product.GeneralInfo.Version = after.GeneralInfo.Version
+		return nil
+	})
+	return created, err
+}
+
+// Patch applies a JSON merge patch to a product, which may change its GeneralInfo and its DetailInfo, and returns its new version.
+func (s *productService) Patch(ctx context.Context, id string, version int64, patch map[string]interface{}) (int64, error) {
+	return s.patch(ctx, id, version, OperationPatch, func(product *Product) error {
+		return MergePatch(product, patch)
+	})
+}
+
+// JSONPatch applies the operations of a JSON Patch to a product; its "test" operations are preconditions of the update.
+func (s *productService) JSONPatch(ctx context.Context, id string, version int64, operations []PatchOperation) (int64, error) {
+	return s.patch(ctx, id, version, OperationPatch, func(product *Product) error {
+		return ApplyJSONPatch(product, operations)
+	})
+}
+
+// patch loads a product, changes it with apply and updates it, in one transaction. The id, the version and the deletion
+// of the product cannot be changed, nor its status but by a transition; a changed DetailInfo is set in its stocks,
+// its availability is derived again from its total stock, and the whole product is validated.
+// The update checks the version which was loaded, or the expected version when it is set, and returns the new version.
+func (s *productService) patch(ctx context.Context, id string, version int64, operation AuditOperation, apply func(product *Product) error) (int64, error) {
+	return s.mutate(ctx, id, operation, func(ctx context.Context) (int64, error) {
+		current, err := s.repository.Load(ctx, id)
+		if err != nil {
+			return -1, requireExisting(err, version)
+		}
+		if version > 0 && version != current.GeneralInfo.Version {
+			return -1, &VersionMismatchError{Resource: "product", Id: id}
+		}
+		product := *current
+		product.Stocks = append([]ProductDetails(nil), current.Stocks...)
+		if err = apply(&product); err != nil {
+			return -1, err
+		}
+		product.GeneralInfo.Id = id
+		product.GeneralInfo.Version = current.GeneralInfo.Version
+		product.GeneralInfo.DeletedAt = current.GeneralInfo.DeletedAt
+		product.GeneralInfo.DeletedBy = current.GeneralInfo.DeletedBy
+		product.DetailInfo.ProductID = id
+		if product.DetailInfo != current.DetailInfo {
+			product.Stocks = nil
+		}
+		if err = deriveStock(current, &product); err != nil {
+			return -1, err
+		}
+		derivePrice(current, &product)
+		if err = s.checkStatus(current, &product); err != nil {
+			return -1, err
+		}
+		if err = s.validate(ctx, &product); err != nil {
+			return -1, err
+		}
+		if _, err = s.repository.Update(ctx, &product); err != nil {
+			return -1, err
+		}
+		return product.GeneralInfo.Version, nil
+	})
+}
+func (s *productService) Delete(ctx context.Context, id string, version int64) (int64, error) {
+	return s.mutate(ctx, id, OperationDelete, func(ctx context.Context) (int64, error) {
+		n, err := s.repository.Delete(ctx, id, version)
+		return n, requireExisting(err, version)
+	})
+}
+func (s *productService) Restore(ctx context.Context, id string, version int64) (int64, error) {
+	return s.mutate(ctx, id, OperationRestore, func(ctx context.Context) (int64, error) {
+		n, err := s.repository.Restore(ctx, id, version)
+		return n, requireExisting(err, version)
+	})
+}
+func (s *productService) Purge(ctx context.Context, before time.Time) (int64, error) {
+	var res int64
+	err := s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = s.repository.Purge(ctx, before)
+		return err
+	})
 	if err != nil {
-		return -1, nil
+		return -1, err
 	}
-	ctx = context.WithValue(ctx, "tx", tx)
-	res, err := s.repository.Update(ctx, product)
+	return res, nil
+}
+func (s *productService) Export(ctx context.Context, filter *ProductFilter, write func(product *Product) error) error {
+	return s.repository.Export(ctx, filter, write)
+}
+func (s *productService) History(ctx context.Context, id string, limit int64, offset int64) ([]ProductAudit, int64, error) {
+	return s.auditRepository.History(ctx, id, limit, offset)
+}
+
+// mutate runs a write in a transaction, and records its audit entry and its event in the same transaction.
+func (s *productService) mutate(ctx context.Context, id string, operation AuditOperation, write func(ctx context.Context) (int64, error)) (int64, error) {
+	var res int64
+	err := s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = s.record(ctx, id, operation, write)
+		return err
+	})
 	if err != nil {
-		er2 := tx.Rollback()
-		if er2 != nil {
-			return -1, er2
-		}
+		return -1, err
 	}
-	if err = tx.Commit(); err != nil {
-		er2 := tx.Rollback()
-		if er2 != nil {
-			return -1, er2
+	return res, nil
+}
+
+// record runs the write and stores its audit entry and its event, in the transaction of ctx. The product is locked
+// when its state before the write is read, so that the audit entry tells the state which the write changed.
+func (s *productService) record(ctx context.Context, id string, operation AuditOperation, write func(ctx context.Context) (int64, error)) (int64, error) {
+	return s.recordProduct(ctx, id, operation, func(ctx context.Context, before *Product) (int64, error) {
+		return write(ctx)
+	})
+}
+
+// recordProduct runs the write as record does, with the product it has locked, or nil when it does not exist.
+func (s *productService) recordProduct(ctx context.Context, id string, operation AuditOperation, write func(ctx context.Context, before *Product) (int64, error)) (int64, error) {
+	var before *Product
+	if operation != OperationCreate {
+		product, err := s.lockIfExists(ctx, id)
+		if err != nil {
+			return -1, err
 		}
+		before = product
 	}
-	return res, err
-}
-func (s *productService) Patch(ctx context.Context, product map[string]interface{}) (int64, error) {
-	tx, err := s.db.Begin()
+	res, err := write(ctx, before)
 	if err != nil {
-		return -1, nil
+		return -1, err
 	}
-	ctx = context.WithValue(ctx, "tx", tx)
-	res, err := s.repository.Patch(ctx, product)
+	after, err := s.loadIfExists(ctx, id)
 	if err != nil {
-		er2 := tx.Rollback()
-		if er2 != nil {
-			return -1, er2
-		}
+		return -1, err
 	}
-	if err = tx.Commit(); err != nil {
-		er2 := tx.Rollback()
-		if er2 != nil {
-			return -1, er2
-		}
+	if err = s.recordChange(ctx, id, operation, before, after); err != nil {
+		return -1, err
 	}
-	return res, err
+	return res, nil
 }
-func (s *productService) Delete(ctx context.Context, id string) (int64, error) {
-	tx, err := s.db.Begin()
+
+// recordChange stores the audit entry and the event of a change, and the new price in the price history when the change
+// sets it, in the transaction of ctx.
+func (s *productService) recordChange(ctx context.Context, id string, operation AuditOperation, before *Product, after *Product) error {
+	audit, err := NewProductAudit(ctx, id, operation, before, after)
 	if err != nil {
-		return -1, nil
+		return err
 	}
-	ctx = context.WithValue(ctx, "tx", tx)
-	res, err := s.repository.Delete(ctx, id)
+	if err = s.auditRepository.Insert(ctx, audit); err != nil {
+		return err
+	}
+	event, err := NewProductEvent(ctx, id, operation, after)
 	if err != nil {
-		er2 := tx.Rollback()
-		if er2 != nil {
-			return -1, er2
-		}
+		return err
 	}
-	if err = tx.Commit(); err != nil {
-		er2 := tx.Rollback()
-		if er2 != nil {
-			return -1, er2
-		}
+	if err = s.outboxRepository.Insert(ctx, event); err != nil {
+		return err
+	}
+	// the changes of the price by schedules are recorded by MaterializePrices
+	if operation == OperationPrice || after == nil || (before != nil && before.GeneralInfo.Price == after.GeneralInfo.Price) {
+		return nil
+	}
+	change := &PriceChange{ProductId: id, Price: after.GeneralInfo.Price, Actor: ActorFromContext(ctx), ChangedAt: time.Now()}
+	if before != nil {
+		change.PreviousPrice = &before.GeneralInfo.Price
+	}
+	return s.priceRepository.InsertChange(ctx, change)
+}
+
+// requireExisting turns the NotFoundError of a write with AnyVersion into a VersionMismatchError, since its precondition
+// requires the product to exist.
+func requireExisting(err error, version int64) error {
+	if notFound, ok := err.(*NotFoundError); ok && version == AnyVersion {
+		return &VersionMismatchError{Resource: notFound.Resource, Id: notFound.Id, Missing: true}
 	}
-	return res, err
+	return err
 }
 
-func checkProductGeneralReq(productGeneral ProductGeneral) error {
-	if productGeneral.Id == "" {
-		return errors.New("product request has no productId")
+// loadIfExists loads a product, returning nil when it does not exist or is deleted.
+func (s *productService) loadIfExists(ctx context.Context, id string) (*Product, error) {
+	product, err := s.repository.Load(ctx, id)
+	if _, ok := err.(*NotFoundError); ok {
+		return nil, nil
 	}
+	return product, err
+}
 
-	//count := 0
-	//v := reflect.ValueOf(productGeneral)
-	//values := make([]interface{}, v.NumField())
-	//
-	//for i := 0; i < v.NumField(); i++ {
-	//	values[i] = v.Field(i).Interface()
-	//	if values[i] == "" {
-	//		count++
-	//	}
-	//}
-	//
-	//if count == v.NumField() {
-	//	return errors.New("product general request has no data")
-	//}
+// lockIfExists locks a product and loads it, in the transaction of ctx, returning nil when it does not exist or is deleted.
+func (s *productService) lockIfExists(ctx context.Context, id string) (*Product, error) {
+	product, err := s.repository.LoadForUpdate(ctx, id)
+	if _, ok := err.(*NotFoundError); ok {
+		return nil, nil
+	}
+	if conflict, ok := err.(*ConflictError); ok && conflict.Code == CodeDeleted {
+		return nil, nil
+	}
+	return product, err
+}
 
+func (s *productService) validate(ctx context.Context, product *Product) error {
+	errs, err := s.check(ctx, product)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
 	return nil
 }
+
+// check validates a product and its stocks.
+func (s *productService) check(ctx context.Context, product *Product) ([]ErrorMessage, error) {
+	errs, err := s.validator.Validate(ctx, product)
+	if err != nil {
+		return nil, err
+	}
+	stockErrs, err := s.checkStocks(ctx, product.Stocks)
+	if err != nil {
+		return nil, err
+	}
+	return append(errs, stockErrs...), nil
+}
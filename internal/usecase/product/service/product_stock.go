@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// Stocks returns the stocks of a product, one per storage.
+func (s *productService) Stocks(ctx context.Context, id string) ([]ProductDetails, error) {
+	product, err := s.repository.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return product.Stocks, nil
+}
+
+// SetStock sets the stock of a product in the storage of stock, and returns the new version of the product;
+// the availability of the product follows its new total stock.
+func (s *productService) SetStock(ctx context.Context, id string, version int64, stock ProductDetails) (int64, error) {
+	return s.patch(ctx, id, version, OperationStock, func(product *Product) error {
+		product.Stocks = SetStock(product.Stocks, stock)
+		return nil
+	})
+}
+
+// deriveStock sets the stocks a product is written with, then its DetailInfo which summarizes them,
+// and whether it is available, which depends on its total stock.
+func deriveStock(current *Product, product *Product) error {
+	id := product.GeneralInfo.Id
+	stocks, err := stocksOf(current, product)
+	if err != nil {
+		return err
+	}
+	for i := range stocks {
+		stocks[i].ProductID = id
+	}
+	product.Stocks = stocks
+	product.DetailInfo = SummarizeStocks(id, stocks)
+	product.GeneralInfo.Available = TotalStock(stocks) > 0
+	return nil
+}
+
+// stocksOf returns the stocks a product is written with: its stocks when they are set. Otherwise, the stocks of
+// the current product, where a changed DetailInfo replaces the stock of its storage, or the only stock,
+// so that a product kept in one storage can be moved to another.
+func stocksOf(current *Product, product *Product) ([]ProductDetails, error) {
+	if len(product.Stocks) > 0 {
+		return product.Stocks, nil
+	}
+	details := product.DetailInfo
+	details.ProductID = product.GeneralInfo.Id
+	var stocks []ProductDetails
+	if current != nil {
+		stocks = current.Stocks
+		if details == current.DetailInfo {
+			return append([]ProductDetails(nil), stocks...), nil
+		}
+	}
+	if details.IsEmpty() {
+		return append([]ProductDetails(nil), stocks...), nil
+	}
+	if len(stocks) == 1 {
+		stocks = nil
+	} else if len(stocks) > 1 && len(details.Storage) == 0 {
+		return nil, &ValidationError{Errors: []ErrorMessage{{Field: "storage", Code: "required", Message: "storage is required, since the product is kept in several storages"}}}
+	}
+	return SetStock(stocks, details), nil
+}
+
+// checkStocks validates each stock, and checks that there is one stock per storage.
+func (s *productService) checkStocks(ctx context.Context, stocks []ProductDetails) ([]ErrorMessage, error) {
+	var errs []ErrorMessage
+	storages := make(map[string]bool, len(stocks))
+	for i := range stocks {
+		stockErrs, err := s.validator.Validate(ctx, &stocks[i])
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range stockErrs {
+			e.Field = fmt.Sprintf("stocks[%d].%s", i, e.Field)
+			errs = append(errs, e)
+		}
+		if storages[stocks[i].Storage] {
+			errs = append(errs, ErrorMessage{Field: fmt.Sprintf("stocks[%d].storage", i), Code: "unique", Message: fmt.Sprintf("storage '%s' has several stocks", stocks[i].Storage)})
+		}
+		storages[stocks[i].Storage] = true
+	}
+	return errs, nil
+}
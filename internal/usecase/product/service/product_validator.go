@@ -0,0 +1,62 @@
+package service
+
+import (
+	"reflect"
+	"regexp"
+	"unicode"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+var productNameRegex = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N} .,'&()/_+#-]*$`)
+
+// NewProductValidator returns a Validator with the custom rules referenced by the product model tags, which it checks.
+func NewProductValidator() (*Validator, error) {
+	v := NewValidator()
+	v.Register("productName", validateProductName)
+	v.Register("description", validateDescription)
+	v.Register("price", validatePrice)
+	v.Register("currency", validateCurrency)
+	v.Register("status", validateStatus)
+	v.Register("reason", validateReason)
+	if err := v.Check(Product{}, ProductTransition{}, Reservation{}, StockMovement{}, ScheduledPrice{}); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func validateProductName(value reflect.Value, param string) bool {
+	return value.Kind() == reflect.String && productNameRegex.MatchString(value.String())
+}
+
+func validateDescription(value reflect.Value, param string) bool {
+	if value.Kind() != reflect.String {
+		return false
+	}
+	for _, r := range value.String() {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+func validatePrice(value reflect.Value, param string) bool {
+	amount, ok := value.Interface().(Amount)
+	return ok && amount > 0
+}
+
+func validateCurrency(value reflect.Value, param string) bool {
+	currency, ok := value.Interface().(Currency)
+	return ok && currency.Valid()
+}
+
+func validateStatus(value reflect.Value, param string) bool {
+	status, ok := value.Interface().(ProductStatus)
+	return ok && status.Valid()
+}
+
+func validateReason(value reflect.Value, param string) bool {
+	reason, ok := value.Interface().(MovementReason)
+	return ok && reason.Valid()
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// PurgeJob periodically removes the products which have been soft deleted for longer than the retention.
+type PurgeJob struct {
+	service   ProductService
+	retention time.Duration
+	interval  time.Duration
+	logError  func(context.Context, string)
+}
+
+func NewPurgeJob(service ProductService, retention time.Duration, interval time.Duration, logError func(context.Context, string)) *PurgeJob {
+	return &PurgeJob{service: service, retention: retention, interval: interval, logError: logError}
+}
+
+// Run purges once per interval until ctx is cancelled.
+func (j *PurgeJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := j.service.Purge(ctx, time.Now().Add(-j.retention)); err != nil && j.logError != nil {
+				j.logError(ctx, fmt.Sprintf("cannot purge deleted products: %s", err.Error()))
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// MoveStock applies a movement to the stock of a product in a storage, records it in the ledger,
+// and derives the availability of the product from its new total stock, in one transaction.
+func (s *productService) MoveStock(ctx context.Context, id string, movement *StockMovement) error {
+	errs, err := s.validator.Validate(ctx, movement)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	movement.ProductId = id
+	return s.mutateStock(ctx, id, func(ctx context.Context, product *Product) error {
+		return s.adjustStock(ctx, product, movement)
+	})
+}
+
+// StockMovements returns the movements of the stock of a product, most recent first, and their total.
+func (s *productService) StockMovements(ctx context.Context, id string, limit int64, offset int64) ([]StockMovement, int64, error) {
+	return s.movementRepository.List(ctx, id, limit, offset)
+}
+
+// mutateStock runs a write of the stock of a product as mutate does, with the product it has locked;
+// the product must exist.
+func (s *productService) mutateStock(ctx context.Context, id string, write func(ctx context.Context, product *Product) error) error {
+	return s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		_, err := s.recordProduct(ctx, id, OperationStock, func(ctx context.Context, product *Product) (int64, error) {
+			if product == nil {
+				return -1, &NotFoundError{Resource: "product", Id: id}
+			}
+			return 1, write(ctx, product)
+		})
+		return err
+	})
+}
+
+// adjustStock adds the delta of a movement to the stock of a locked product in a storage, and derives the availability
+// of the product from its total stock. It runs in the transaction of ctx. Stock cannot be added to an archived product,
+// which has none.
+func (s *productService) adjustStock(ctx context.Context, product *Product, movement *StockMovement) error {
+	if movement.Delta > 0 && product.GeneralInfo.Status == StatusArchived {
+		return &ValidationError{Errors: []ErrorMessage{{Field: "delta", Code: "max", Param: "0", Message: "stock cannot be added to an archived product"}}}
+	}
+	stocks, err := s.moveStock(ctx, movement)
+	if err != nil {
+		return err
+	}
+	_, err = s.repository.UpdateAvailability(ctx, movement.ProductId, TotalStock(stocks) > 0)
+	return err
+}
+
+// moveStock adds the delta of a movement to the stock of a product in a storage, deleted or not, as a single
+// conditional update, so that concurrent movements neither lose an update nor make the stock negative. Then it records
+// the movement with the balance of the storage, and returns the stocks of the product. It runs in the transaction of ctx.
+func (s *productService) moveStock(ctx context.Context, movement *StockMovement) ([]ProductDetails, error) {
+	id, storage := movement.ProductId, movement.Storage
+	n, err := s.repository.AdjustStock(ctx, id, storage, movement.Delta)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, &ConflictError{Resource: "product", Id: id, Code: CodeOutOfStock, Message: fmt.Sprintf("product '%s' has not enough stock in storage '%s'", id, storage)}
+	}
+	stocks, err := s.repository.LoadStocks(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, stock := range stocks {
+		if stock.Storage == storage {
+			movement.Balance = stock.InStockAmount
+		}
+	}
+	movement.Actor = ActorFromContext(ctx)
+	movement.MovedAt = time.Now()
+	return stocks, s.movementRepository.Insert(ctx, movement)
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	. "go-service/internal/usecase/product/domain"
+)
+
+// ValidateRule reports whether the field value satisfies the rule; param is the text after "=" in the tag.
+type ValidateRule func(value reflect.Value, param string) bool
+
+// Validator evaluates the `validate` struct tags of a model.
+// Apart from "required", rules are not applied to zero values, so optional fields can be left empty.
+type Validator struct {
+	rules map[string]ValidateRule
+}
+
+func NewValidator() *Validator {
+	v := &Validator{rules: make(map[string]ValidateRule)}
+	v.Register("max", validateMax)
+	v.Register("min", validateMin)
+	return v
+}
+
+func (v *Validator) Register(code string, rule ValidateRule) {
+	v.rules[code] = rule
+}
+
+// Validate checks every tagged field of model, including the fields of nested structs, which are named with the name
+// of their struct as a prefix, such as GeneralInfo.price.
+func (v *Validator) Validate(ctx context.Context, model interface{}) ([]ErrorMessage, error) {
+	val := reflect.Indirect(reflect.ValueOf(model))
+	if val.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot validate %T", model)
+	}
+	var errs []ErrorMessage
+	err := v.validateStruct(val, "", &errs)
+	return errs, err
+}
+
+func (v *Validator) validateStruct(val reflect.Value, prefix string, errs *[]ErrorMessage) error {
+	t := val.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok {
+			if field.Type.Kind() == reflect.Struct {
+				if err := v.validateStruct(val.Field(i), prefix+jsonName(field)+".", errs); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		name := jsonName(field)
+		path := prefix + name
+		fv := val.Field(i)
+		for _, r := range strings.Split(tag, ",") {
+			code, param := splitRule(r)
+			if len(code) == 0 {
+				continue
+			}
+			if code == "required" {
+				if fv.IsZero() {
+					*errs = append(*errs, ErrorMessage{Field: path, Code: code, Message: fmt.Sprintf("%s is required", name)})
+					break
+				}
+				continue
+			}
+			rule, ok := v.rules[code]
+			if !ok {
+				return fmt.Errorf("unknown validation rule %q on field %s", code, field.Name)
+			}
+			if !fv.IsZero() && !rule(fv, param) {
+				*errs = append(*errs, ErrorMessage{Field: path, Code: code, Param: param, Message: message(name, code, param)})
+			}
+		}
+	}
+	return nil
+}
+
+// Check verifies the validate tags of models, and of the structs they contain, so that a rule which is not registered,
+// or a min or max which is not an integer, fails when the validator is built rather than when a request is validated.
+func (v *Validator) Check(models ...interface{}) error {
+	seen := make(map[reflect.Type]bool)
+	for _, model := range models {
+		if err := v.checkType(reflect.TypeOf(model), seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Validator) checkType(t reflect.Type, seen map[reflect.Type]bool) error {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag, _ := field.Tag.Lookup("validate")
+		for _, r := range strings.Split(tag, ",") {
+			code, param := splitRule(r)
+			if len(code) == 0 || code == "required" {
+				continue
+			}
+			if _, ok := v.rules[code]; !ok {
+				return fmt.Errorf("unknown validation rule %q on field %s.%s", code, t.Name(), field.Name)
+			}
+			if code == "max" || code == "min" {
+				if _, err := strconv.ParseInt(param, 10, 64); err != nil {
+					return fmt.Errorf("validation rule %q on field %s.%s must have an integer, not %q", code, t.Name(), field.Name, param)
+				}
+			}
+		}
+		if err := v.checkType(field.Type, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitRule splits a rule of a validate tag into its code and the param after "=".
+func splitRule(rule string) (string, string) {
+	if j := strings.Index(rule, "="); j >= 0 {
+		return rule[:j], rule[j+1:]
+	}
+	return rule, ""
+}
+
+func jsonName(field reflect.StructField) string {
+	if tag, ok := field.Tag.Lookup("json"); ok {
+		if name := strings.Split(tag, ",")[0]; len(name) > 0 && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func message(field, code, param string) string {
+	switch code {
+	case "max":
+		return fmt.Sprintf("%s must not exceed %s", field, param)
+	case "min":
+		return fmt.Sprintf("%s must be at least %s", field, param)
+	default:
+		return fmt.Sprintf("%s is not a valid %s", field, code)
+	}
+}
+
+func validateMax(value reflect.Value, param string) bool {
+	n, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return false
+	}
+	size, ok := measure(value)
+	return ok && size <= n
+}
+
+func validateMin(value reflect.Value, param string) bool {
+	n, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return false
+	}
+	size, ok := measure(value)
+	return ok && size >= n
+}
+
+// measure returns the rune count of strings, the length of slices and maps, and the value of integers.
+func measure(value reflect.Value) (int64, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return int64(utf8.RuneCountInString(value.String())), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return int64(value.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(value.Uint()), true
+	}
+	return 0, false
+}